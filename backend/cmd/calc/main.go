@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"lab01/calculator"
+)
+
+const usage = `Usage: go run cmd/calc/main.go [-session FILE] [EXPRESSION]
+
+Evaluates EXPRESSION and prints the result, or starts an interactive session
+when none is given. Inputs may assign variables, as in "x = 3", and ans holds
+the last result. With -session, variables, ans and history are loaded from
+FILE if it exists and saved back to it on exit.`
+
+const help = `Enter an expression such as "2 * (3 + sqrt(16))" or an assignment such as
+"x = ans / 2". Commands:
+  :vars         list variables
+  :history      show the session history
+  :save FILE    save the session to FILE
+  :load FILE    replace the session with one saved in FILE
+  :help         show this help
+  :quit         leave (Ctrl-D works too)`
+
+// prompt is shown before each input in an interactive session
+const prompt = "> "
+
+func main() {
+	sessionFile := flag.String("session", "", "file to load the session from and save it to")
+	flag.Usage = func() { fmt.Fprintln(os.Stderr, usage) }
+	flag.Parse()
+
+	session := calculator.NewSession()
+	if *sessionFile != "" {
+		if err := load(*sessionFile, session); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Fatalf("Failed to load session: %v", err)
+		}
+	}
+
+	ok := true
+	if flag.NArg() > 0 {
+		input := strings.Join(flag.Args(), " ")
+		ok = evaluate(session, input, "", os.Stdout, os.Stderr)
+	} else {
+		repl(session, os.Stdin, os.Stdout, os.Stderr, isTerminal(os.Stdin))
+	}
+
+	if *sessionFile != "" {
+		if err := save(*sessionFile, session); err != nil {
+			log.Fatalf("Failed to save session: %v", err)
+		}
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+// repl evaluates one input per line until in is exhausted or :quit is entered
+func repl(session *calculator.Session, in io.Reader, out, errOut io.Writer, interactive bool) {
+	indent := ""
+	if interactive {
+		indent = prompt
+		fmt.Fprintln(out, `Type ":help" for help.`)
+	}
+
+	scanner := bufio.NewScanner(in)
+	for {
+		if interactive {
+			fmt.Fprint(out, prompt)
+		}
+		if !scanner.Scan() {
+			break
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, ":") {
+			evaluate(session, line, indent, out, errOut)
+			continue
+		}
+
+		command, arg, _ := strings.Cut(line, " ")
+		arg = strings.TrimSpace(arg)
+		switch command {
+		case ":quit", ":q":
+			return
+		case ":help":
+			fmt.Fprintln(out, help)
+		case ":vars":
+			vars := session.Vars()
+			names := make([]string, 0, len(vars))
+			for name := range vars {
+				names = append(names, name)
+			}
+			slices.Sort(names)
+			for _, name := range names {
+				fmt.Fprintf(out, "%s = %s\n", name, format(vars[name]))
+			}
+			fmt.Fprintf(out, "ans = %s\n", format(session.Ans()))
+		case ":history":
+			for i, entry := range session.History() {
+				fmt.Fprintf(out, "%3d  %s => %s\n", i+1, entry.Input, format(entry.Result))
+			}
+		case ":save", ":load":
+			if arg == "" {
+				fmt.Fprintf(errOut, "error: %s needs a file name\n", command)
+				continue
+			}
+			var err error
+			if command == ":save" {
+				err = save(arg, session)
+			} else {
+				err = load(arg, session)
+			}
+			if err != nil {
+				fmt.Fprintf(errOut, "error: %v\n", err)
+			}
+		default:
+			fmt.Fprintf(errOut, "error: unknown command %s, try :help\n", command)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(errOut, "error: %v\n", err)
+	}
+	if interactive {
+		fmt.Fprintln(out)
+	}
+}
+
+// evaluate prints the result of input, or the error with a caret under the
+// column it refers to. indent is what precedes the input on screen; without
+// one the input is echoed above the caret.
+func evaluate(session *calculator.Session, input, indent string, out, errOut io.Writer) bool {
+	result, err := session.Eval(input)
+	if err == nil {
+		fmt.Fprintln(out, format(result))
+		return true
+	}
+
+	col := 0
+	var (
+		syntaxErr *calculator.SyntaxError
+		evalErr   *calculator.EvalError
+	)
+	switch {
+	case errors.As(err, &syntaxErr):
+		col = syntaxErr.Col
+	case errors.As(err, &evalErr):
+		col = evalErr.Col
+	}
+	if col > 0 {
+		if indent == "" {
+			fmt.Fprintln(errOut, input)
+		}
+		fmt.Fprintf(errOut, "%s^\n", strings.Repeat(" ", len([]rune(indent))+col-1))
+	}
+	fmt.Fprintf(errOut, "error: %v\n", err)
+	return false
+}
+
+// format prints a result as briefly as possible without losing precision
+func format(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// load replaces session with the one saved in path
+func load(path string, session *calculator.Session) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, session); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// save writes session to path
+func save(path string, session *calculator.Session) error {
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// isTerminal reports whether f is an interactive terminal rather than a pipe or file
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
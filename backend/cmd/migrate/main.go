@@ -1,41 +1,137 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 
+	_ "github.com/lib/pq"
 	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/config"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/migrate"
 )
 
+const usage = `Usage: go run cmd/migrate/main.go [-dir migrations] <command>
+
+Commands:
+  up          apply all pending migrations
+  down [N]    roll back the last N migrations (default 1)
+  status      list migrations and whether they are applied
+  goto N      migrate up or down to version N
+  force N     mark versions up to N as applied without running SQL
+  create NAME create an empty up/down migration pair`
+
 func main() {
-	if len(os.Args) < 2 {
-		log.Fatal("Usage: go run cmd/migrate/main.go [up|down]")
+	dir := flag.String("dir", "migrations", "directory containing migration files")
+	flag.Usage = func() { fmt.Fprintln(os.Stderr, usage) }
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) < 1 {
+		log.Fatal(usage)
+	}
+
+	if args[0] == "create" {
+		if len(args) != 2 {
+			log.Fatal("Usage: create NAME")
+		}
+		up, down, err := migrate.Create(*dir, args[1])
+		if err != nil {
+			log.Fatalf("Failed to create migration: %v", err)
+		}
+		fmt.Printf("Created %s\nCreated %s\n", up, down)
+		return
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	migrations, err := migrate.LoadDir(*dir)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	db, err := sql.Open("postgres", cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
 	}
+	defer db.Close()
+	db.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	db.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	db.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
 
-	cfg := config.Load()
-	direction := os.Args[1]
+	ctx := context.Background()
+	m := migrate.New(db, migrations)
 
-	switch direction {
+	switch args[0] {
 	case "up":
-		runMigrationsUp(cfg)
+		n, err := m.Up(ctx)
+		report("Applied", n, err)
 	case "down":
-		runMigrationsDown(cfg)
+		steps := 1
+		if len(args) > 1 {
+			steps = parseNumber(args[1])
+		}
+		n, err := m.Down(ctx, steps)
+		report("Rolled back", n, err)
+	case "goto":
+		n, err := m.Goto(ctx, requireVersion(args))
+		report("Ran", n, err)
+	case "force":
+		version := requireVersion(args)
+		if err := m.Force(ctx, version); err != nil {
+			log.Fatalf("Failed to force version: %v", err)
+		}
+		fmt.Printf("Forced version %d\n", version)
+	case "status":
+		printStatus(ctx, m)
 	default:
-		log.Fatal("Invalid direction. Use 'up' or 'down'")
+		log.Fatal(usage)
 	}
 }
 
-func runMigrationsUp(cfg *config.Config) {
-	fmt.Printf("🔄 Running migrations UP against %s\n", cfg.DatabaseURL)
-	// TODO: Implement actual migration logic
-	// This would typically use a migration library like golang-migrate
-	fmt.Println("✅ Migrations completed successfully")
+// report prints how many steps ran and exits on error
+func report(action string, n int, err error) {
+	fmt.Printf("%s %d migration(s)\n", action, n)
+	if err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	}
+}
+
+// printStatus prints one line per known migration
+func printStatus(ctx context.Context, m *migrate.Migrator) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		log.Fatalf("Failed to read status: %v", err)
+	}
+
+	for _, s := range statuses {
+		state := "pending"
+		if s.Applied {
+			state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%04d  %-40s %s\n", s.Version, s.Name, state)
+	}
 }
 
-func runMigrationsDown(cfg *config.Config) {
-	fmt.Printf("🔄 Running migrations DOWN against %s\n", cfg.DatabaseURL)
-	// TODO: Implement actual migration logic
-	// This would typically use a migration library like golang-migrate
-	fmt.Println("✅ Migrations rollback completed successfully")
+// requireVersion returns the version argument of goto and force
+func requireVersion(args []string) int64 {
+	if len(args) != 2 {
+		log.Fatalf("Usage: %s N", args[0])
+	}
+	return int64(parseNumber(args[1]))
+}
+
+// parseNumber parses a non-negative integer argument
+func parseNumber(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		log.Fatalf("Invalid number %q", s)
+	}
+	return n
 }
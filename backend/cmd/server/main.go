@@ -2,7 +2,11 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,64 +14,188 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	_ "github.com/lib/pq"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/auth"
 	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/config"
 	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/handlers"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/health"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/logging"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/metrics"
 	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/middleware"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/ratelimit"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/server"
+
+	"lab01/taskmanager"
 )
 
 func main() {
 	// Load configuration
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	// Initialize structured logging
+	logger := logging.New(cfg, os.Stdout)
+	slog.SetDefault(logger)
 
 	// Initialize Gin router
-	if cfg.Env == "production" {
+	if cfg.IsProduction() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
 	router := gin.New()
+	// Only believe X-Forwarded-For from our own proxies, or clients could pick their own rate limit key
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		logger.Error("invalid trusted proxies", "error", err)
+		os.Exit(1)
+	}
 
 	// Add middleware
-	router.Use(gin.Logger())
+	router.Use(middleware.RequestID())
+	router.Use(middleware.RequestLogger(logger))
+
+	// Metrics go before Recovery and CORS so panics and rejected requests are
+	// counted too
+	var registry *metrics.Registry
+	if cfg.MetricsEnabled {
+		registry = metrics.NewRegistry()
+		router.Use(middleware.Metrics(registry))
+	}
 	router.Use(gin.Recovery())
-	router.Use(middleware.CORS())
+	router.Use(middleware.CORS(cfg))
+
+	// Metrics endpoint, served on the main port or on a separate admin port
+	var adminServer *http.Server
+	if registry != nil {
+		if cfg.MetricsPort == 0 {
+			router.GET("/metrics", gin.WrapH(registry.Handler()))
+		} else {
+			adminMux := http.NewServeMux()
+			adminMux.Handle("/metrics", registry.Handler())
+			adminServer = &http.Server{
+				Addr:              fmt.Sprintf(":%d", cfg.MetricsPort),
+				Handler:           adminMux,
+				ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			}
+		}
+	}
+
+	// Database connection, used for readiness and the postgres task store
+	db, err := sql.Open("postgres", cfg.DatabaseURL)
+	if err != nil {
+		logger.Error("failed to open database", "error", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	db.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	db.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+
+	// Health check endpoints
+	checks := health.NewRegistry()
+	checks.Register("postgres", health.CheckerFunc(db.PingContext), true, 2*time.Second)
+	healthHandler := handlers.NewHealthHandler(checks)
+
+	router.GET("/health", healthHandler.Ready)
+	router.GET("/health/live", healthHandler.Live)
+	router.GET("/health/ready", healthHandler.Ready)
 
-	// Health check endpoint
-	router.GET("/health", handlers.HealthCheck)
+	// Authentication
+	issuer := auth.NewIssuer(cfg.JWTSecret, cfg.AccessTokenTTL, cfg.RefreshTokenTTL, auth.NewMemoryStore())
+	authHandler := handlers.NewAuthHandler(issuer, auth.ParseStaticUsers(cfg.AuthUsers))
+
+	// Rate limiting per client and route group
+	limits := ratelimit.NewMemoryStore(10*time.Minute, 100_000)
+	apiLimit := ratelimit.Every(cfg.RateLimitAPI.Requests, cfg.RateLimitAPI.Per)
+	authLimit := ratelimit.Every(cfg.RateLimitAuth.Requests, cfg.RateLimitAuth.Per)
 
 	// API routes
-	api := router.Group("/api/v1")
+	var taskHandler *handlers.TaskHandler
+	api := router.Group("/api/v1", auth.Optional(issuer), middleware.RateLimit(limits, "api", apiLimit))
 	{
 		api.GET("/ping", handlers.Ping)
+		api.POST("/users/validate", handlers.ValidateUser)
+
+		authGroup := api.Group("/auth", middleware.RateLimit(limits, "auth", authLimit))
+		authGroup.POST("/login", authHandler.Login)
+		authGroup.POST("/refresh", authHandler.Refresh)
+		authGroup.POST("/logout", auth.Middleware(issuer), authHandler.Logout)
+
+		taskStore, err := newTaskStore(cfg, db)
+		if err != nil {
+			logger.Error("failed to open task store", "store", cfg.TaskStore, "error", err)
+			os.Exit(1)
+		}
+		if closer, ok := taskStore.(io.Closer); ok {
+			defer closer.Close()
+		}
+
+		taskHandler = handlers.NewTaskHandler(taskmanager.NewTaskManagerWithStore(taskStore))
+		taskHandler.Register(api)
+		handlers.NewCalcHandler().Register(api)
 		// Add more routes as needed
 	}
 
 	// Create HTTP server
-	server := &http.Server{
-		Addr:    ":" + cfg.Port,
-		Handler: router,
+	srv, err := server.New(cfg, router)
+	if err != nil {
+		logger.Error("failed to configure server", "error", err)
+		os.Exit(1)
 	}
+	srv.RegisterOnShutdown(taskHandler.CloseStreams)
 
 	// Start server in a goroutine
 	go func() {
-		log.Printf("🚀 Server starting on port %s", cfg.Port)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
+		logger.Info("server starting", "port", cfg.Port, "env", cfg.Env, "tls", cfg.TLSEnabled())
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("failed to start server", "error", err)
+			os.Exit(1)
 		}
 	}()
 
+	if adminServer != nil {
+		go func() {
+			logger.Info("admin server starting", "port", cfg.MetricsPort)
+			if err := adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("failed to start admin server", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	log.Println("🛑 Shutting down server...")
+	logger.Info("shutting down server")
 
-	// Give outstanding requests 10 seconds to complete
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	// Fail readiness first, then give outstanding requests time to complete
+	if err := srv.Drain(checks); err != nil {
+		logger.Error("server forced to shutdown", "error", err)
+		os.Exit(1)
+	}
 
-	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+	if adminServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		if err := adminServer.Shutdown(ctx); err != nil {
+			logger.Error("admin server forced to shutdown", "error", err)
+		}
 	}
 
-	log.Println("✅ Server exited")
+	logger.Info("server exited")
+}
+
+// newTaskStore creates the task store selected by TASK_STORE. The postgres
+// store expects the tasks table created by the migrations.
+func newTaskStore(cfg *config.Config, db *sql.DB) (taskmanager.TaskStore, error) {
+	switch cfg.TaskStore {
+	case "postgres":
+		return taskmanager.NewSQLStore(db, taskmanager.DialectPostgres), nil
+	case "file":
+		return taskmanager.OpenFileStore(cfg.TaskStoreFile)
+	default:
+		return taskmanager.NewMemoryStore(), nil
+	}
 }
@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestIssuer() *Issuer {
+	return NewIssuer("test-secret", time.Minute, time.Hour, NewMemoryStore())
+}
+
+func TestIssueAndVerify(t *testing.T) {
+	issuer := newTestIssuer()
+
+	pair, err := issuer.IssuePair("alice")
+	if err != nil {
+		t.Fatalf("IssuePair failed: %v", err)
+	}
+
+	claims, err := issuer.Verify(pair.AccessToken, TokenTypeAccess)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if claims.Subject != "alice" {
+		t.Errorf("Expected subject alice, got %s", claims.Subject)
+	}
+
+	if _, err := issuer.Verify(pair.RefreshToken, TokenTypeAccess); !errors.Is(err, ErrWrongTokenType) {
+		t.Errorf("Expected ErrWrongTokenType for refresh token, got %v", err)
+	}
+
+	other := NewIssuer("other-secret", time.Minute, time.Hour, NewMemoryStore())
+	if _, err := other.Verify(pair.AccessToken, TokenTypeAccess); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken for foreign signature, got %v", err)
+	}
+
+	if _, err := issuer.Verify("not.a.token", TokenTypeAccess); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken for garbage, got %v", err)
+	}
+}
+
+func TestExpiredToken(t *testing.T) {
+	issuer := newTestIssuer()
+	pair, err := issuer.IssuePair("alice")
+	if err != nil {
+		t.Fatalf("IssuePair failed: %v", err)
+	}
+
+	issuer.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if _, err := issuer.Verify(pair.AccessToken, TokenTypeAccess); !errors.Is(err, ErrExpiredToken) {
+		t.Errorf("Expected ErrExpiredToken, got %v", err)
+	}
+}
+
+func TestRefreshRotates(t *testing.T) {
+	issuer := newTestIssuer()
+	pair, err := issuer.IssuePair("alice")
+	if err != nil {
+		t.Fatalf("IssuePair failed: %v", err)
+	}
+
+	next, err := issuer.Refresh(pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if next.RefreshToken == pair.RefreshToken {
+		t.Error("Expected a new refresh token")
+	}
+
+	if _, err := issuer.Refresh(pair.RefreshToken); !errors.Is(err, ErrRevokedToken) {
+		t.Errorf("Expected reused refresh token to be revoked, got %v", err)
+	}
+
+	if _, err := issuer.Refresh(next.RefreshToken); err != nil {
+		t.Errorf("Expected rotated refresh token to work, got %v", err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	issuer := newTestIssuer()
+
+	router := gin.New()
+	router.GET("/me", Middleware(issuer), func(c *gin.Context) {
+		claims, _ := ClaimsFromContext(c)
+		c.String(http.StatusOK, claims.Subject)
+	})
+
+	pair, err := issuer.IssuePair("alice")
+	if err != nil {
+		t.Fatalf("IssuePair failed: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{name: "valid token", header: "Bearer " + pair.AccessToken, status: http.StatusOK},
+		{name: "missing header", header: "", status: http.StatusUnauthorized},
+		{name: "wrong scheme", header: "Basic " + pair.AccessToken, status: http.StatusUnauthorized},
+		{name: "refresh token", header: "Bearer " + pair.RefreshToken, status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, w.Code)
+			}
+			if tt.status == http.StatusOK && w.Body.String() != "alice" {
+				t.Errorf("Expected subject alice in body, got %q", w.Body.String())
+			}
+		})
+	}
+
+	claims, _ := issuer.Verify(pair.AccessToken, TokenTypeAccess)
+	issuer.Revoke(claims)
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+pair.AccessToken)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected revoked token to be rejected, got %d", w.Code)
+	}
+}
+
+func TestStaticUsers(t *testing.T) {
+	users := ParseStaticUsers("alice:secret, bob:hunter2, broken")
+
+	if subject, err := users.Authenticate("alice", "secret"); err != nil || subject != "alice" {
+		t.Errorf("Expected alice to authenticate, got %q, %v", subject, err)
+	}
+	if _, err := users.Authenticate("alice", "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials, got %v", err)
+	}
+	if _, err := users.Authenticate("broken", ""); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials for unknown user, got %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"strings"
+)
+
+// Authenticator checks a username and password and returns the token subject
+type Authenticator interface {
+	Authenticate(username, password string) (subject string, err error)
+}
+
+// StaticUsers authenticates against a fixed set of username/password pairs
+type StaticUsers map[string]string
+
+// ParseStaticUsers parses a comma-separated list of username:password pairs
+func ParseStaticUsers(list string) StaticUsers {
+	users := make(StaticUsers)
+	for _, entry := range strings.Split(list, ",") {
+		name, password, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || name == "" || password == "" {
+			continue
+		}
+		users[name] = password
+	}
+	return users
+}
+
+// Authenticate returns username as the subject when the password matches
+func (u StaticUsers) Authenticate(username, password string) (string, error) {
+	expected, ok := u[username]
+
+	// Compare fixed-size digests so the timing does not leak the password length
+	got := sha256.Sum256([]byte(password))
+	want := sha256.Sum256([]byte(expected))
+	if subtle.ConstantTimeCompare(got[:], want[:]) != 1 || !ok {
+		return "", ErrInvalidCredentials
+	}
+	return username, nil
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// claimsKey is the gin.Context key holding the verified *Claims
+const claimsKey = "auth.claims"
+
+// Middleware rejects requests without a valid access token and stores its claims on the context
+func Middleware(issuer *Issuer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bearerToken(c.GetHeader("Authorization"))
+		if !ok {
+			c.Header("WWW-Authenticate", `Bearer realm="api"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+			return
+		}
+
+		claims, err := issuer.Verify(token, TokenTypeAccess)
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set(claimsKey, claims)
+		c.Next()
+	}
+}
+
+// Optional stores the claims of a valid access token on the context, but lets
+// requests without one through so later handlers can decide
+func Optional(issuer *Issuer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token, ok := bearerToken(c.GetHeader("Authorization")); ok {
+			if claims, err := issuer.Verify(token, TokenTypeAccess); err == nil {
+				c.Set(claimsKey, claims)
+			}
+		}
+		c.Next()
+	}
+}
+
+// ClaimsFromContext returns the claims stored by Middleware
+func ClaimsFromContext(c *gin.Context) (*Claims, bool) {
+	value, ok := c.Get(claimsKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := value.(*Claims)
+	return claims, ok
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer <token>" header
+func bearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
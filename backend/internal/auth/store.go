@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// RevocationStore remembers revoked token IDs until they would have expired anyway
+type RevocationStore interface {
+	// Revoke marks id as revoked and reports whether it was not revoked before
+	Revoke(id string, expiresAt time.Time) bool
+	// IsRevoked reports whether id has been revoked
+	IsRevoked(id string) bool
+}
+
+// MemoryStore is an in-memory RevocationStore
+type MemoryStore struct {
+	mu      sync.Mutex
+	revoked map[string]time.Time
+	now     func() time.Time
+}
+
+// NewMemoryStore creates an empty in-memory revocation store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		revoked: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+// Revoke marks id as revoked until expiresAt
+func (s *MemoryStore) Revoke(id string, expiresAt time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune()
+
+	if _, ok := s.revoked[id]; ok {
+		return false
+	}
+	s.revoked[id] = expiresAt
+	return true
+}
+
+// IsRevoked reports whether id has been revoked
+func (s *MemoryStore) IsRevoked(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.revoked[id]
+	return ok
+}
+
+// prune drops entries whose tokens have expired, since Verify rejects them anyway
+func (s *MemoryStore) prune() {
+	now := s.now()
+	for id, expiresAt := range s.revoked {
+		if !now.Before(expiresAt) {
+			delete(s.revoked, id)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Token types carried in the typ claim
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+// Predefined errors
+var (
+	ErrInvalidToken       = errors.New("invalid token")
+	ErrExpiredToken       = errors.New("token has expired")
+	ErrRevokedToken       = errors.New("token has been revoked")
+	ErrWrongTokenType     = errors.New("wrong token type")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// jwtHeader is the fixed header of every token we issue
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Claims are the verified contents of a token
+type Claims struct {
+	Subject   string `json:"sub"`
+	Type      string `json:"typ"`
+	ID        string `json:"jti"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// ExpiresTime returns the expiry as a time.Time
+func (c *Claims) ExpiresTime() time.Time {
+	return time.Unix(c.ExpiresAt, 0)
+}
+
+// TokenPair is returned on login and refresh
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// Issuer signs and verifies HS256 tokens
+type Issuer struct {
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	store      RevocationStore
+	now        func() time.Time
+}
+
+// NewIssuer creates an issuer that signs with secret and checks revocations in store
+func NewIssuer(secret string, accessTTL, refreshTTL time.Duration, store RevocationStore) *Issuer {
+	return &Issuer{
+		secret:     []byte(secret),
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+		store:      store,
+		now:        time.Now,
+	}
+}
+
+// IssuePair creates a new access and refresh token for subject
+func (i *Issuer) IssuePair(subject string) (TokenPair, error) {
+	access, err := i.sign(subject, TokenTypeAccess, i.accessTTL)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	refresh, err := i.sign(subject, TokenTypeRefresh, i.refreshTTL)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	return TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(i.accessTTL.Seconds()),
+	}, nil
+}
+
+// Verify checks the signature, expiry, type and revocation status of token
+func (i *Issuer) Verify(token, tokenType string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, i.mac(parts[0]+"."+parts[1])) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.ID == "" {
+		return nil, ErrInvalidToken
+	}
+
+	if claims.Type != tokenType {
+		return nil, ErrWrongTokenType
+	}
+	if !i.now().Before(claims.ExpiresTime()) {
+		return nil, ErrExpiredToken
+	}
+	if i.store.IsRevoked(claims.ID) {
+		return nil, ErrRevokedToken
+	}
+
+	return &claims, nil
+}
+
+// Refresh rotates a refresh token: the old one is revoked and a new pair is issued
+func (i *Issuer) Refresh(refreshToken string) (TokenPair, error) {
+	claims, err := i.Verify(refreshToken, TokenTypeRefresh)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	// Revoking first means a replayed refresh token can never mint a second pair
+	if !i.store.Revoke(claims.ID, claims.ExpiresTime()) {
+		return TokenPair{}, ErrRevokedToken
+	}
+
+	return i.IssuePair(claims.Subject)
+}
+
+// Revoke denies any further use of the token described by claims
+func (i *Issuer) Revoke(claims *Claims) {
+	i.store.Revoke(claims.ID, claims.ExpiresTime())
+}
+
+// sign builds a signed token for subject
+func (i *Issuer) sign(subject, tokenType string, ttl time.Duration) (string, error) {
+	id, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	now := i.now()
+	payload, err := json.Marshal(Claims{
+		Subject:   subject,
+		Type:      tokenType,
+		ID:        id,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(i.mac(unsigned)), nil
+}
+
+// mac computes the HMAC-SHA256 of data with the issuer secret
+func (i *Issuer) mac(data string) []byte {
+	h := hmac.New(sha256.New, i.secret)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// newTokenID returns a random token identifier
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate token id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -1,85 +1,247 @@
 package config
 
 import (
+	"log/slog"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoad(t *testing.T) {
 	// Test default values
-	cfg := Load()
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
 
 	if cfg.Env != "development" {
 		t.Errorf("Expected default env to be 'development', got '%s'", cfg.Env)
 	}
 
-	if cfg.Port != "8080" {
-		t.Errorf("Expected default port to be '8080', got '%s'", cfg.Port)
+	if cfg.Port != 8080 {
+		t.Errorf("Expected default port to be 8080, got %d", cfg.Port)
 	}
 
 	if cfg.JWTSecret != "your-jwt-secret-key" {
 		t.Errorf("Expected default JWT secret to be 'your-jwt-secret-key', got '%s'", cfg.JWTSecret)
 	}
+
+	if cfg.AccessTokenTTL != 15*time.Minute {
+		t.Errorf("Expected default access token TTL to be 15m, got %v", cfg.AccessTokenTTL)
+	}
+
+	if !reflect.DeepEqual(cfg.CORSOrigins, []string{"http://localhost:3000"}) {
+		t.Errorf("Expected default CORS origins, got %v", cfg.CORSOrigins)
+	}
+
+	if len(cfg.TrustedProxies) != 0 {
+		t.Errorf("Expected no trusted proxies by default, got %v", cfg.TrustedProxies)
+	}
+
+	if cfg.LogLevel != slog.LevelInfo || cfg.LogFormat != "json" {
+		t.Errorf("Expected default logging to be info/json, got %v/%s", cfg.LogLevel, cfg.LogFormat)
+	}
 }
 
 func TestLoadWithEnvVars(t *testing.T) {
 	// Set environment variables
-	os.Setenv("ENV", "test")
-	os.Setenv("PORT", "9000")
-	os.Setenv("JWT_SECRET", "test-secret")
-
-	// Clean up after test
-	defer func() {
-		os.Unsetenv("ENV")
-		os.Unsetenv("PORT")
-		os.Unsetenv("JWT_SECRET")
-	}()
-
-	cfg := Load()
+	t.Setenv("ENV", "test")
+	t.Setenv("PORT", "9000")
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("DB_MAX_OPEN_CONNS", "25")
+	t.Setenv("DB_CONN_MAX_LIFETIME", "5m")
+	t.Setenv("CORS_ORIGINS", "http://localhost:3000, http://localhost:8080")
+	t.Setenv("RATE_LIMIT_API", "50/30s")
+	t.Setenv("RATE_LIMIT_AUTH", "0")
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.168.1.1")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
 
 	if cfg.Env != "test" {
 		t.Errorf("Expected env to be 'test', got '%s'", cfg.Env)
 	}
 
-	if cfg.Port != "9000" {
-		t.Errorf("Expected port to be '9000', got '%s'", cfg.Port)
+	if cfg.Port != 9000 {
+		t.Errorf("Expected port to be 9000, got %d", cfg.Port)
 	}
 
 	if cfg.JWTSecret != "test-secret" {
 		t.Errorf("Expected JWT secret to be 'test-secret', got '%s'", cfg.JWTSecret)
 	}
+
+	if cfg.DBMaxOpenConns != 25 {
+		t.Errorf("Expected DB max open conns to be 25, got %d", cfg.DBMaxOpenConns)
+	}
+
+	if cfg.DBConnMaxLifetime != 5*time.Minute {
+		t.Errorf("Expected DB conn max lifetime to be 5m, got %v", cfg.DBConnMaxLifetime)
+	}
+
+	want := []string{"http://localhost:3000", "http://localhost:8080"}
+	if !reflect.DeepEqual(cfg.CORSOrigins, want) {
+		t.Errorf("Expected CORS origins %v, got %v", want, cfg.CORSOrigins)
+	}
+
+	if cfg.RateLimitAPI != (RateLimit{Requests: 50, Per: 30 * time.Second}) {
+		t.Errorf("Expected API rate limit 50/30s, got %+v", cfg.RateLimitAPI)
+	}
+
+	if cfg.RateLimitAuth != (RateLimit{}) {
+		t.Errorf("Expected auth rate limit to be disabled, got %+v", cfg.RateLimitAuth)
+	}
+
+	if !reflect.DeepEqual(cfg.TrustedProxies, []string{"10.0.0.0/8", "192.168.1.1"}) {
+		t.Errorf("Expected trusted proxies from env, got %v", cfg.TrustedProxies)
+	}
 }
 
-func TestGetEnvAsInt(t *testing.T) {
-	// Test with valid integer
-	os.Setenv("TEST_INT", "42")
-	defer os.Unsetenv("TEST_INT")
+func TestLoadReportsAllInvalidKeys(t *testing.T) {
+	t.Setenv("PORT", "eighty")
+	t.Setenv("DB_MAX_IDLE_CONNS", "many")
+	t.Setenv("JWT_ACCESS_TTL", "soon")
+	t.Setenv("LOG_LEVEL", "loud")
+	t.Setenv("LOG_FORMAT", "xml")
+	t.Setenv("RATE_LIMIT_API", "lots")
+	t.Setenv("TASK_STORE", "redis")
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.1,proxy.internal")
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("Expected error, got none")
+	}
 
-	result := getEnvAsInt("TEST_INT", 10)
-	if result != 42 {
-		t.Errorf("Expected 42, got %d", result)
+	for _, key := range []string{"PORT", "DB_MAX_IDLE_CONNS", "JWT_ACCESS_TTL", "LOG_LEVEL", "LOG_FORMAT", "RATE_LIMIT_API", "TASK_STORE", "TRUSTED_PROXIES"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("Expected error to mention %s, got %v", key, err)
+		}
 	}
+}
 
-	// Test with fallback
-	result = getEnvAsInt("NON_EXISTENT", 10)
-	if result != 10 {
-		t.Errorf("Expected fallback value 10, got %d", result)
+func TestLoadRefusesDefaultSecretsInProduction(t *testing.T) {
+	t.Setenv("ENV", "production")
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("Expected error, got none")
+	}
+	if !strings.Contains(err.Error(), "JWT_SECRET") || !strings.Contains(err.Error(), "DATABASE_URL") {
+		t.Errorf("Expected both default secrets to be reported, got %v", err)
+	}
+
+	t.Setenv("JWT_SECRET", "a-real-production-secret")
+	t.Setenv("DATABASE_URL", "postgres://app:strong@db:5432/app")
+
+	if _, err := Load(); err != nil {
+		t.Errorf("Unexpected error with real secrets: %v", err)
+	}
+}
+
+func TestLoadFromFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "yaml",
+			file: "config.yaml",
+			content: `port: 9100
+jwt_access_ttl: 5m
+cors_origins:
+  - https://app.example.com
+  - https://*.example.com
+`,
+		},
+		{
+			name: "toml",
+			file: "config.toml",
+			content: `port = 9100
+jwt_access_ttl = "5m"
+cors_origins = ["https://app.example.com", "https://*.example.com"]
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("CONFIG_FILE", path)
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if cfg.Port != 9100 {
+				t.Errorf("Expected port from file to be 9100, got %d", cfg.Port)
+			}
+			if cfg.AccessTokenTTL != 5*time.Minute {
+				t.Errorf("Expected access token TTL from file to be 5m, got %v", cfg.AccessTokenTTL)
+			}
+			if len(cfg.CORSOrigins) != 2 {
+				t.Errorf("Expected 2 CORS origins from file, got %v", cfg.CORSOrigins)
+			}
+
+			// Environment variables override the file
+			t.Setenv("PORT", "9200")
+			cfg, err = Load()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if cfg.Port != 9200 {
+				t.Errorf("Expected env to override file port, got %d", cfg.Port)
+			}
+		})
 	}
 }
 
-func TestGetEnvAsBool(t *testing.T) {
+func TestLoadRejectsUnknownFileKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "port: 9100\njwt_acess_ttl: 5m\nlog_levle: debug\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("LOG_FORMAT", "xml")
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("Expected error, got none")
+	}
+	for _, want := range []string{"jwt_acess_ttl: unknown key", "log_levle: unknown key", "LOG_FORMAT"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention %q, got %v", want, err)
+		}
+	}
+}
+
+func TestLoaderBool(t *testing.T) {
 	// Test with valid boolean
-	os.Setenv("TEST_BOOL", "true")
-	defer os.Unsetenv("TEST_BOOL")
+	t.Setenv("TEST_BOOL", "true")
 
-	result := getEnvAsBool("TEST_BOOL", false)
-	if result != true {
+	l := &loader{}
+	if result := l.bool("TEST_BOOL", false); result != true {
 		t.Errorf("Expected true, got %v", result)
 	}
 
 	// Test with fallback
-	result = getEnvAsBool("NON_EXISTENT", false)
-	if result != false {
+	if result := l.bool("NON_EXISTENT", false); result != false {
 		t.Errorf("Expected fallback value false, got %v", result)
 	}
+
+	// Test with invalid value
+	t.Setenv("TEST_BOOL", "maybe")
+	l.bool("TEST_BOOL", false)
+	if len(l.errs) != 1 {
+		t.Errorf("Expected 1 error for invalid boolean, got %d", len(l.errs))
+	}
 }
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/auth"
+)
+
+// AuthHandler serves the login, refresh and logout endpoints
+type AuthHandler struct {
+	issuer *auth.Issuer
+	users  auth.Authenticator
+}
+
+// NewAuthHandler creates an AuthHandler
+func NewAuthHandler(issuer *auth.Issuer, users auth.Authenticator) *AuthHandler {
+	return &AuthHandler{issuer: issuer, users: users}
+}
+
+type loginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type logoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Login exchanges a username and password for a token pair
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req loginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subject, err := h.users.Authenticate(req.Username, req.Password)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": auth.ErrInvalidCredentials.Error()})
+		return
+	}
+
+	pair, err := h.issuer.IssuePair(subject)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to issue token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pair)
+}
+
+// Refresh rotates a refresh token into a new token pair
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pair, err := h.issuer.Refresh(req.RefreshToken)
+	if err != nil {
+		status := http.StatusUnauthorized
+		if !isTokenError(err) {
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pair)
+}
+
+// Logout revokes the caller's access token and, if given, their refresh token
+func (h *AuthHandler) Logout(c *gin.Context) {
+	claims, ok := auth.ClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+		return
+	}
+
+	var req logoutRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if req.RefreshToken != "" {
+		refresh, err := h.issuer.Verify(req.RefreshToken, auth.TokenTypeRefresh)
+		if err == nil && refresh.Subject == claims.Subject {
+			h.issuer.Revoke(refresh)
+		}
+	}
+
+	h.issuer.Revoke(claims)
+	c.Status(http.StatusNoContent)
+}
+
+// isTokenError reports whether err means the client sent a bad token
+func isTokenError(err error) bool {
+	return errors.Is(err, auth.ErrInvalidToken) ||
+		errors.Is(err, auth.ErrExpiredToken) ||
+		errors.Is(err, auth.ErrRevokedToken) ||
+		errors.Is(err, auth.ErrWrongTokenType)
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"lab01/calculator"
+)
+
+// Calculator session limits
+const (
+	// calcSessionTTL is how long an unused session is kept
+	calcSessionTTL = time.Hour
+	// maxCalcSessions bounds the memory held by sessions
+	maxCalcSessions = 10000
+	// maxCalcBodyBytes limits the body of POST /calc
+	maxCalcBodyBytes = 16 << 10
+	// maxCalcSessionBytes limits the body of POST /calc/sessions, enough for a
+	// saved session with full history and variables
+	maxCalcSessionBytes = 2 << 20
+)
+
+// Calculator error codes returned in the "code" field of error responses
+const (
+	CalcInvalidRequest   = "invalid_request"
+	CalcSyntaxError      = "syntax_error"
+	CalcDivisionByZero   = "division_by_zero"
+	CalcDomainError      = "domain_error"
+	CalcOverflow         = "overflow"
+	CalcUnknownName      = "unknown_name"
+	CalcReservedName     = "reserved_name"
+	CalcTooManyVariables = "too_many_variables"
+	CalcUnknownOperation = "unknown_operation"
+	CalcSessionNotFound  = "session_not_found"
+	CalcInvalidSession   = "invalid_session"
+	CalcTooManySessions  = "too_many_sessions"
+)
+
+// calcOperators maps the operations accepted by POST /calc to operators
+var calcOperators = map[string]string{
+	"add":      "+",
+	"subtract": "-",
+	"multiply": "*",
+	"divide":   "/",
+	"mod":      "%",
+	"power":    "^",
+}
+
+// CalcHandler serves the /calc endpoints backed by the lab01 calculator.
+// Sessions keep variables, ans and history in memory between requests.
+type CalcHandler struct {
+	mu       sync.Mutex
+	sessions map[string]*calcSession
+	now      func() time.Time
+}
+
+type calcSession struct {
+	session  *calculator.Session
+	lastUsed time.Time
+}
+
+// NewCalcHandler creates a CalcHandler with no sessions
+func NewCalcHandler() *CalcHandler {
+	return &CalcHandler{sessions: make(map[string]*calcSession), now: time.Now}
+}
+
+// Register adds the calculator routes to group
+func (h *CalcHandler) Register(group *gin.RouterGroup) {
+	group.POST("/calc", h.Eval)
+	group.POST("/calc/sessions", h.CreateSession)
+	group.GET("/calc/sessions/:id", h.GetSession)
+	group.DELETE("/calc/sessions/:id", h.DeleteSession)
+}
+
+type calcRequest struct {
+	// Session is the ID of a session to evaluate in; without it nothing is kept
+	Session    string    `json:"session"`
+	Expression string    `json:"expression" binding:"max=1000"`
+	Operation  string    `json:"operation"`
+	Operands   []float64 `json:"operands" binding:"max=100"`
+}
+
+// Eval evaluates either an expression, which may assign a variable as in
+// "x = 3", or an operation applied left to right over two or more operands
+func (h *CalcHandler) Eval(c *gin.Context) {
+	var req calcRequest
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxCalcBodyBytes)
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if calcTooLarge(c, err, maxCalcBodyBytes) {
+			return
+		}
+		calcFailure(c, http.StatusBadRequest, CalcInvalidRequest, "invalid JSON body")
+		return
+	}
+
+	input := req.Expression
+	switch {
+	case req.Expression != "" && req.Operation != "":
+		calcFailure(c, http.StatusBadRequest, CalcInvalidRequest, "send either expression or operation, not both")
+		return
+	case req.Operation != "":
+		var ok bool
+		if input, ok = operationExpression(c, req.Operation, req.Operands); !ok {
+			return
+		}
+	case strings.TrimSpace(req.Expression) == "":
+		calcFailure(c, http.StatusBadRequest, CalcInvalidRequest, "expression or operation is required")
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	session := calculator.NewSession()
+	if req.Session != "" {
+		s, ok := h.session(c, req.Session)
+		if !ok {
+			return
+		}
+		session = s.session
+	}
+
+	result, err := session.Eval(input)
+	if err != nil {
+		calcError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"input": input, "result": result, "ans": session.Ans()})
+}
+
+// CreateSession starts a session, empty or loaded from a previously saved one
+// sent as the body
+func (h *CalcHandler) CreateSession(c *gin.Context) {
+	session := calculator.NewSession()
+	if c.Request.ContentLength != 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxCalcSessionBytes)
+		if err := c.ShouldBindJSON(session); err != nil {
+			if calcTooLarge(c, err, maxCalcSessionBytes) {
+				return
+			}
+			calcFailure(c, http.StatusUnprocessableEntity, CalcInvalidSession, err.Error())
+			return
+		}
+	}
+
+	id, err := newSessionID()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.prune()
+	if len(h.sessions) >= maxCalcSessions {
+		calcFailure(c, http.StatusServiceUnavailable, CalcTooManySessions, "too many calculator sessions")
+		return
+	}
+	h.sessions[id] = &calcSession{session: session, lastUsed: h.now()}
+	c.JSON(http.StatusCreated, gin.H{"id": id, "session": session})
+}
+
+// GetSession returns a session's ans, variables and history in the form
+// CreateSession accepts, so it can be saved and loaded later
+func (h *CalcHandler) GetSession(c *gin.Context) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.session(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, s.session)
+}
+
+// DeleteSession discards a session
+func (h *CalcHandler) DeleteSession(c *gin.Context) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.session(c, c.Param("id")); !ok {
+		return
+	}
+	delete(h.sessions, c.Param("id"))
+	c.Status(http.StatusNoContent)
+}
+
+// session looks up a live session and marks it used, writing a 404 response
+// if there is none; the caller holds mu
+func (h *CalcHandler) session(c *gin.Context, id string) (*calcSession, bool) {
+	h.prune()
+	s, ok := h.sessions[id]
+	if !ok {
+		calcFailure(c, http.StatusNotFound, CalcSessionNotFound, "calculator session not found")
+		return nil, false
+	}
+	s.lastUsed = h.now()
+	return s, true
+}
+
+// prune drops sessions unused for longer than calcSessionTTL; the caller holds mu
+func (h *CalcHandler) prune() {
+	now := h.now()
+	for id, s := range h.sessions {
+		if now.Sub(s.lastUsed) >= calcSessionTTL {
+			delete(h.sessions, id)
+		}
+	}
+}
+
+// operationExpression turns an operation and its operands into an expression
+// such as "6 / (-3)", writing an error response if they are invalid
+func operationExpression(c *gin.Context, operation string, operands []float64) (string, bool) {
+	op, ok := calcOperators[operation]
+	if !ok {
+		calcFailure(c, http.StatusUnprocessableEntity, CalcUnknownOperation, fmt.Sprintf("unknown operation %q", operation))
+		return "", false
+	}
+	if len(operands) < 2 {
+		calcFailure(c, http.StatusBadRequest, CalcInvalidRequest, "operation needs at least two operands")
+		return "", false
+	}
+
+	terms := make([]string, len(operands))
+	for i, operand := range operands {
+		terms[i] = strconv.FormatFloat(operand, 'g', -1, 64)
+		if operand < 0 {
+			terms[i] = "(" + terms[i] + ")"
+		}
+	}
+	return strings.Join(terms, " "+op+" "), true
+}
+
+// calcError maps calculator errors to 422 responses with an error code and
+// the column the error was found at
+func calcError(c *gin.Context, err error) {
+	var (
+		syntaxErr *calculator.SyntaxError
+		evalErr   *calculator.EvalError
+	)
+	switch {
+	case errors.As(err, &syntaxErr):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "code": CalcSyntaxError, "col": syntaxErr.Col})
+	case errors.As(err, &evalErr):
+		code := CalcDomainError
+		switch {
+		case errors.Is(err, calculator.ErrDivisionByZero):
+			code = CalcDivisionByZero
+		case errors.Is(err, calculator.ErrOverflow):
+			code = CalcOverflow
+		case errors.Is(err, calculator.ErrUnknownName):
+			code = CalcUnknownName
+		case errors.Is(err, calculator.ErrReservedName):
+			code = CalcReservedName
+		case errors.Is(err, calculator.ErrTooManyVariables):
+			code = CalcTooManyVariables
+		}
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "code": code, "col": evalErr.Col})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
+
+// calcTooLarge writes a 413 response if err is from a body over limit bytes
+func calcTooLarge(c *gin.Context, err error, limit int64) bool {
+	var tooLarge *http.MaxBytesError
+	if !errors.As(err, &tooLarge) {
+		return false
+	}
+	calcFailure(c, http.StatusRequestEntityTooLarge, CalcInvalidRequest, fmt.Sprintf("body must not exceed %d bytes", limit))
+	return true
+}
+
+// calcFailure writes an error response with a calculator error code
+func calcFailure(c *gin.Context, status int, code, message string) {
+	c.JSON(status, gin.H{"error": message, "code": code})
+}
+
+// newSessionID returns a random session identifier
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate session id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"lab01/calculator"
+)
+
+func newCalcRouter() (*gin.Engine, *CalcHandler) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	h := NewCalcHandler()
+	h.Register(router.Group("/api/v1"))
+	return router, h
+}
+
+func TestCalcEval(t *testing.T) {
+	router, _ := newCalcRouter()
+
+	tests := []struct {
+		name     string
+		body     string
+		status   int
+		expected string
+	}{
+		{"expression", `{"expression":"2 * (3 + 4)"}`, http.StatusOK, `{"ans":14,"input":"2 * (3 + 4)","result":14}`},
+		{"operation", `{"operation":"divide","operands":[9,-3,2]}`, http.StatusOK, `{"ans":-1.5,"input":"9 / (-3) / 2","result":-1.5}`},
+		{"division by zero", `{"expression":"1 / 0"}`, http.StatusUnprocessableEntity, `{"code":"division_by_zero","col":3,"error":"division by zero at col 3"}`},
+		{"division by zero operation", `{"operation":"divide","operands":[1,0]}`, http.StatusUnprocessableEntity, `{"code":"division_by_zero","col":3,"error":"division by zero at col 3"}`},
+		{"syntax error", `{"expression":"(1 + 2))"}`, http.StatusUnprocessableEntity, `{"code":"syntax_error","col":8,"error":"unexpected ')' at col 8"}`},
+		{"domain error", `{"expression":"sqrt(-1)"}`, http.StatusUnprocessableEntity, `{"code":"domain_error","col":1,"error":"argument out of domain at col 1"}`},
+		{"unknown name", `{"expression":"x + 1"}`, http.StatusUnprocessableEntity, `{"code":"unknown_name","col":1,"error":"unknown name 'x' at col 1"}`},
+		{"unknown function", `{"expression":"2 * foo(1)"}`, http.StatusUnprocessableEntity, `{"code":"unknown_name","col":5,"error":"unknown name 'foo' at col 5"}`},
+		{"too large", `{"expression":"` + strings.Repeat(" ", maxCalcBodyBytes) + `1"}`, http.StatusRequestEntityTooLarge, `{"code":"invalid_request","error":"body must not exceed 16384 bytes"}`},
+		{"unknown operation", `{"operation":"root","operands":[4,2]}`, http.StatusUnprocessableEntity, `{"code":"unknown_operation","error":"unknown operation \"root\""}`},
+		{"too few operands", `{"operation":"add","operands":[1]}`, http.StatusBadRequest, `{"code":"invalid_request","error":"operation needs at least two operands"}`},
+		{"both", `{"expression":"1","operation":"add","operands":[1,2]}`, http.StatusBadRequest, `{"code":"invalid_request","error":"send either expression or operation, not both"}`},
+		{"neither", `{}`, http.StatusBadRequest, `{"code":"invalid_request","error":"expression or operation is required"}`},
+		{"malformed", `{`, http.StatusBadRequest, `{"code":"invalid_request","error":"invalid JSON body"}`},
+		{"missing session", `{"expression":"1","session":"nope"}`, http.StatusNotFound, `{"code":"session_not_found","error":"calculator session not found"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doJSON(router, http.MethodPost, "/api/v1/calc", tt.body)
+			if w.Code != tt.status || w.Body.String() != tt.expected {
+				t.Errorf("Expected %d %s, got %d %s", tt.status, tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCalcSessions(t *testing.T) {
+	router, h := newCalcRouter()
+
+	w := doJSON(router, http.MethodPost, "/api/v1/calc/sessions", "")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+
+	for _, expr := range []string{"x = 3", "ans * x"} {
+		w = doJSON(router, http.MethodPost, "/api/v1/calc", `{"session":"`+created.ID+`","expression":"`+expr+`"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %q, got %d: %s", expr, w.Code, w.Body.String())
+		}
+	}
+	w = doJSON(router, http.MethodPost, "/api/v1/calc", `{"session":"`+created.ID+`","expression":"ans = 1"}`)
+	if w.Code != http.StatusUnprocessableEntity || w.Body.String() != `{"code":"reserved_name","col":1,"error":"cannot assign to reserved name 'ans' at col 1"}` {
+		t.Errorf("Expected reserved_name, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// A saved session loads back into a new one
+	w = doJSON(router, http.MethodGet, "/api/v1/calc/sessions/"+created.ID, "")
+	saved := w.Body.String()
+	expected := `{"ans":9,"variables":{"x":3},"history":[{"input":"x = 3","result":3},{"input":"ans * x","result":9}]}`
+	if w.Code != http.StatusOK || saved != expected {
+		t.Fatalf("Expected %s, got %d: %s", expected, w.Code, saved)
+	}
+	w = doJSON(router, http.MethodPost, "/api/v1/calc/sessions", saved)
+	var loaded struct {
+		ID      string          `json:"id"`
+		Session json.RawMessage `json:"session"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &loaded)
+	if w.Code != http.StatusCreated || loaded.ID == created.ID || string(loaded.Session) != saved {
+		t.Errorf("Expected a new session loaded from the saved one, got %d: %s", w.Code, w.Body.String())
+	}
+	w = doJSON(router, http.MethodPost, "/api/v1/calc/sessions", `{"variables":{"pi":3}}`)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422 for an invalid session, got %d: %s", w.Code, w.Body.String())
+	}
+	vars := make([]string, calculator.MaxVariables+1)
+	for i := range vars {
+		vars[i] = fmt.Sprintf(`"v%d":%d`, i, i)
+	}
+	w = doJSON(router, http.MethodPost, "/api/v1/calc/sessions", `{"variables":{`+strings.Join(vars, ",")+`}}`)
+	if w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), `"code":"invalid_session"`) {
+		t.Errorf("Expected status 422 for too many variables, got %d: %s", w.Code, w.Body.String())
+	}
+	w = doJSON(router, http.MethodPost, "/api/v1/calc/sessions", `{"history":[`+strings.Repeat(`{"input":"1","result":1},`, maxCalcSessionBytes/20)+`]}`)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413 for an oversized session, got %d", w.Code)
+	}
+
+	w = doJSON(router, http.MethodDelete, "/api/v1/calc/sessions/"+created.ID, "")
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", w.Code)
+	}
+	w = doJSON(router, http.MethodGet, "/api/v1/calc/sessions/"+created.ID, "")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 after delete, got %d", w.Code)
+	}
+
+	// Unused sessions expire
+	h.now = func() time.Time { return time.Now().Add(calcSessionTTL) }
+	w = doJSON(router, http.MethodGet, "/api/v1/calc/sessions/"+loaded.ID, "")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an expired session, got %d", w.Code)
+	}
+}
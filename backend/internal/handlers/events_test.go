@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"lab01/taskmanager"
+)
+
+func TestTaskEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewTaskHandler(taskmanager.NewTaskManager())
+	router := gin.New()
+	handler.Register(router.Group("/api/v1"))
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/v1/tasks/events")
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	if w := doJSON(router, http.MethodPost, "/api/v1/tasks", `{"title":"Live"}`); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	expected := []string{"event: created", `data: {"type":"created","after":{"id":1,"title":"Live"`}
+	for _, want := range expected {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read stream: %v", err)
+		}
+		if !strings.HasPrefix(line, want) {
+			t.Errorf("Expected a line starting with %q, got %q", want, line)
+		}
+	}
+
+	// Closing the streams ends the response
+	handler.CloseStreams()
+	for {
+		if _, err := reader.ReadString('\n'); err != nil {
+			break
+		}
+	}
+}
@@ -4,14 +4,48 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/health"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/version"
 )
 
-// HealthCheck returns server health status
-func HealthCheck(c *gin.Context) {
+// serviceName identifies this backend in health responses
+const serviceName = "sum25-go-flutter-course-backend"
+
+// HealthHandler serves the liveness and readiness endpoints
+type HealthHandler struct {
+	checks *health.Registry
+}
+
+// NewHealthHandler creates a HealthHandler backed by the given checks
+func NewHealthHandler(checks *health.Registry) *HealthHandler {
+	return &HealthHandler{checks: checks}
+}
+
+// Live reports that the process is running, without checking dependencies
+func (h *HealthHandler) Live(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
-		"status":  "healthy",
-		"service": "sum25-go-flutter-course-backend",
-		"version": "1.0.0",
+		"status":  health.StatusUp,
+		"service": serviceName,
+		"version": version.Version,
+		"commit":  version.Commit,
+	})
+}
+
+// Ready runs every registered check and returns 503 if a critical one fails
+func (h *HealthHandler) Ready(c *gin.Context) {
+	report := h.checks.Run(c.Request.Context())
+
+	status := http.StatusOK
+	if !report.Healthy() {
+		status = http.StatusServiceUnavailable
+	}
+
+	c.JSON(status, gin.H{
+		"status":  report.Status,
+		"service": serviceName,
+		"version": version.Version,
+		"commit":  version.Commit,
+		"checks":  report.Checks,
 	})
 }
 
@@ -0,0 +1,573 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+
+	"lab01/taskmanager"
+)
+
+// TaskHandler serves the /tasks REST resource backed by a TaskManager
+type TaskHandler struct {
+	tasks *taskmanager.TaskManager
+	// streams is cancelled by CloseStreams to end every open event stream
+	streams     context.Context
+	stopStreams context.CancelFunc
+}
+
+// NewTaskHandler creates a TaskHandler
+func NewTaskHandler(tasks *taskmanager.TaskManager) *TaskHandler {
+	streams, stop := context.WithCancel(context.Background())
+	return &TaskHandler{tasks: tasks, streams: streams, stopStreams: stop}
+}
+
+// Register adds the task routes to group
+func (h *TaskHandler) Register(group *gin.RouterGroup) {
+	group.GET("/tasks", h.List)
+	group.POST("/tasks", h.Create)
+	group.GET("/tasks/export", h.Export)
+	group.POST("/tasks/import", h.Import)
+	group.GET("/tasks/events", h.Events)
+	group.POST("/tasks/undo", h.Undo)
+	group.POST("/tasks/redo", h.Redo)
+	group.GET("/tasks/trash", h.Trash)
+	group.GET("/tasks/ready", h.Ready)
+	group.GET("/tasks/plan", h.Plan)
+	group.POST("/tasks/trash/:id/restore", h.Restore)
+	group.GET("/tasks/:id", h.Get)
+	group.PUT("/tasks/:id", h.Update)
+	group.PATCH("/tasks/:id", h.Patch)
+	group.GET("/tasks/:id/subtasks", h.Subtasks)
+	group.GET("/tasks/:id/history", h.History)
+	group.GET("/tasks/:id/blocks", h.Blocks)
+	group.PUT("/tasks/:id/blockers/:blocker", h.AddBlocker)
+	group.DELETE("/tasks/:id/blockers/:blocker", h.RemoveBlocker)
+	group.DELETE("/tasks/:id", h.Delete)
+}
+
+type createTaskRequest struct {
+	Title       string               `json:"title" binding:"max=200"`
+	Description string               `json:"description" binding:"max=2000"`
+	Priority    taskmanager.Priority `json:"priority"`
+	Tags        []string             `json:"tags" binding:"max=20,dive,max=50"`
+	DueDate     *time.Time           `json:"due_date"`
+	Recurrence  *taskmanager.RRule   `json:"recurrence"`
+	ParentID    int                  `json:"parent_id" binding:"min=0"`
+	BlockedBy   []int                `json:"blocked_by" binding:"max=100,dive,min=1"`
+}
+
+type updateTaskRequest struct {
+	Title       string `json:"title" binding:"max=200"`
+	Description string `json:"description" binding:"max=2000"`
+	Done        bool   `json:"done"`
+}
+
+type patchTaskRequest struct {
+	Title        *string               `json:"title" binding:"omitempty,max=200"`
+	Description  *string               `json:"description" binding:"omitempty,max=2000"`
+	Done         *bool                 `json:"done"`
+	Priority     *taskmanager.Priority `json:"priority"`
+	Tags         *[]string             `json:"tags" binding:"omitempty,max=20,dive,max=50"`
+	DueDate      *time.Time            `json:"due_date"`
+	ClearDueDate bool                  `json:"clear_due_date"`
+	ParentID     *int                  `json:"parent_id" binding:"omitempty,min=0"`
+	// Recurrence is an RFC 5545 RRULE such as "FREQ=WEEKLY;BYDAY=MO"
+	Recurrence      *taskmanager.RRule `json:"recurrence"`
+	ClearRecurrence bool               `json:"clear_recurrence"`
+	BlockedBy       *[]int             `json:"blocked_by" binding:"omitempty,max=100,dive,min=1"`
+}
+
+// List returns tasks filtered, sorted and paged by query parameters:
+// done, q, tag (repeatable), priority (repeatable), due_after, due_before (RFC 3339),
+// sort (field name, "-" prefix for descending), limit and cursor. The cursor for the
+// next page is returned in the X-Next-Cursor header.
+func (h *TaskHandler) List(c *gin.Context) {
+	opts, err := listOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.tasks.QueryTasks(opts)
+	if err != nil {
+		if errors.Is(err, taskmanager.ErrInvalidCursor) || errors.Is(err, taskmanager.ErrInvalidSortField) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		taskError(c, err)
+		return
+	}
+
+	if page.NextCursor != "" {
+		c.Header(NextCursorHeader, page.NextCursor)
+	}
+	c.JSON(http.StatusOK, page.Tasks)
+}
+
+// Create adds a new task
+func (h *TaskHandler) Create(c *gin.Context) {
+	var req createTaskRequest
+	if !bindTaskJSON(c, &req) {
+		return
+	}
+
+	opts := []taskmanager.TaskOption{
+		taskmanager.WithPriority(req.Priority),
+		taskmanager.WithTags(req.Tags...),
+		taskmanager.WithParent(req.ParentID),
+		taskmanager.WithBlockers(req.BlockedBy...),
+	}
+	if req.DueDate != nil {
+		opts = append(opts, taskmanager.WithDueDate(*req.DueDate))
+	}
+	if req.Recurrence != nil {
+		opts = append(opts, taskmanager.WithRecurrence(*req.Recurrence))
+	}
+
+	task, err := h.tasks.AddTask(req.Title, req.Description, opts...)
+	if err != nil {
+		taskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, task)
+}
+
+// maxImportBytes limits the body of POST /tasks/import
+const maxImportBytes = 5 << 20
+
+// exportContentTypes maps export formats to response content types
+var exportContentTypes = map[taskmanager.Format]string{
+	taskmanager.FormatJSON: "application/json; charset=utf-8",
+	taskmanager.FormatCSV:  "text/csv; charset=utf-8",
+	taskmanager.FormatICS:  "text/calendar; charset=utf-8",
+}
+
+type importRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+type importResponse struct {
+	Created int              `json:"created"`
+	Updated int              `json:"updated"`
+	Errors  []importRowError `json:"errors"`
+}
+
+// Export downloads every task; format is json (default), csv or ics
+func (h *TaskHandler) Export(c *gin.Context) {
+	format := taskmanager.Format(c.DefaultQuery("format", string(taskmanager.FormatJSON)))
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json, csv or ics"})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := h.tasks.Export(&buf, format); err != nil {
+		taskError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="tasks.%s"`, format))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// Import reads tasks from the raw request body. format is json (default), csv
+// or ics; mode is append (default), merge or replace. Rows that fail validation
+// are listed in the response and skipped.
+func (h *TaskHandler) Import(c *gin.Context) {
+	format := taskmanager.Format(c.DefaultQuery("format", string(taskmanager.FormatJSON)))
+	mode := taskmanager.ImportMode(c.DefaultQuery("mode", string(taskmanager.ImportAppend)))
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+	result, err := h.tasks.Import(body, format, mode)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("body must not exceed %d bytes", maxImportBytes)})
+			return
+		}
+		// Unknown format or mode, or a file that cannot be parsed at all
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := importResponse{Created: result.Created, Updated: result.Updated, Errors: []importRowError{}}
+	for _, rowErr := range result.Errors {
+		resp.Errors = append(resp.Errors, importRowError{Row: rowErr.Row, Error: rowErr.Err.Error()})
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Get returns one task
+func (h *TaskHandler) Get(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+
+	task, err := h.tasks.GetTask(id)
+	if err != nil {
+		taskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// Update replaces the title, description and done status of a task
+func (h *TaskHandler) Update(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+
+	var req updateTaskRequest
+	if !bindTaskJSON(c, &req) {
+		return
+	}
+
+	task, err := h.tasks.UpdateTask(id, taskmanager.TaskPatch{
+		Title:       &req.Title,
+		Description: &req.Description,
+		Done:        &req.Done,
+	})
+	if err != nil {
+		taskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// Patch changes only the fields present in the request body
+func (h *TaskHandler) Patch(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+
+	var req patchTaskRequest
+	if !bindTaskJSON(c, &req) {
+		return
+	}
+
+	task, err := h.tasks.UpdateTask(id, taskmanager.TaskPatch{
+		Title:        req.Title,
+		Description:  req.Description,
+		Done:         req.Done,
+		Priority:     req.Priority,
+		Tags:         req.Tags,
+		DueDate:      req.DueDate,
+		ClearDueDate: req.ClearDueDate,
+		ParentID:     req.ParentID,
+
+		Recurrence:      req.Recurrence,
+		ClearRecurrence: req.ClearRecurrence,
+		BlockedBy:       req.BlockedBy,
+	})
+	if err != nil {
+		taskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// Subtasks returns the direct sub-tasks of a task
+func (h *TaskHandler) Subtasks(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+
+	tasks, err := h.tasks.Subtasks(id)
+	if err != nil {
+		taskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tasks)
+}
+
+// Delete removes a task
+func (h *TaskHandler) Delete(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+
+	unblocked, err := h.tasks.DeleteTask(id)
+	if err != nil {
+		taskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unblocked": unblocked})
+}
+
+// Ready returns the open tasks whose blockers are all done
+func (h *TaskHandler) Ready(c *gin.Context) {
+	tasks, err := h.tasks.ReadyTasks()
+	if err != nil {
+		taskError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tasks)
+}
+
+// Plan returns the open tasks ordered so that blockers come first
+func (h *TaskHandler) Plan(c *gin.Context) {
+	tasks, err := h.tasks.Plan()
+	if err != nil {
+		taskError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tasks)
+}
+
+// Blocks returns the tasks that wait for a task
+func (h *TaskHandler) Blocks(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+
+	tasks, err := h.tasks.Blocks(id)
+	if err != nil {
+		taskError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tasks)
+}
+
+// AddBlocker makes a task wait for the task in the :blocker path parameter
+func (h *TaskHandler) AddBlocker(c *gin.Context) {
+	id, blocker, ok := blockerIDs(c)
+	if !ok {
+		return
+	}
+
+	task, err := h.tasks.AddBlocker(id, blocker)
+	if err != nil {
+		taskError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, task)
+}
+
+// RemoveBlocker stops a task from waiting for the task in the :blocker path parameter
+func (h *TaskHandler) RemoveBlocker(c *gin.Context) {
+	id, blocker, ok := blockerIDs(c)
+	if !ok {
+		return
+	}
+
+	task, err := h.tasks.RemoveBlocker(id, blocker)
+	if err != nil {
+		taskError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, task)
+}
+
+// blockerIDs parses the :id and :blocker path parameters, writing a 400 response if either is invalid
+func blockerIDs(c *gin.Context) (int, int, bool) {
+	id, ok := taskID(c)
+	if !ok {
+		return 0, 0, false
+	}
+	blocker, err := strconv.Atoi(c.Param("blocker"))
+	if err != nil || blocker <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "blocker must be a positive integer"})
+		return 0, 0, false
+	}
+	return id, blocker, true
+}
+
+// Page size limits for GET /tasks
+const (
+	defaultTaskPageSize = 50
+	maxTaskPageSize     = 200
+)
+
+// NextCursorHeader carries the cursor for the next page of a task list
+const NextCursorHeader = "X-Next-Cursor"
+
+// listOptions parses the GET /tasks query parameters
+func listOptions(c *gin.Context) (taskmanager.ListOptions, error) {
+	opts := taskmanager.ListOptions{
+		Text:   c.Query("q"),
+		Tags:   c.QueryArray("tag"),
+		Cursor: c.Query("cursor"),
+		Limit:  defaultTaskPageSize,
+	}
+
+	if raw, ok := c.GetQuery("done"); ok {
+		done, err := strconv.ParseBool(raw)
+		if err != nil {
+			return opts, errors.New("done must be true or false")
+		}
+		opts.Done = &done
+	}
+	for _, raw := range c.QueryArray("priority") {
+		priority, err := taskmanager.ParsePriority(raw)
+		if err != nil {
+			return opts, err
+		}
+		opts.Priorities = append(opts.Priorities, priority)
+	}
+	dueBounds := []struct {
+		key    string
+		target **time.Time
+	}{
+		{"due_after", &opts.DueAfter},
+		{"due_before", &opts.DueBefore},
+	}
+	for _, bound := range dueBounds {
+		if raw, ok := c.GetQuery(bound.key); ok {
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return opts, fmt.Errorf("%s must be an RFC 3339 timestamp", bound.key)
+			}
+			*bound.target = &t
+		}
+	}
+	if raw := c.Query("sort"); raw != "" {
+		field, descending := strings.CutPrefix(raw, "-")
+		opts.SortBy = taskmanager.SortField(field)
+		opts.Descending = descending
+		if !opts.SortBy.Valid() {
+			return opts, fmt.Errorf("cannot sort by %q", field)
+		}
+	}
+	if raw, ok := c.GetQuery("limit"); ok {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxTaskPageSize {
+			return opts, fmt.Errorf("limit must be between 1 and %d", maxTaskPageSize)
+		}
+		opts.Limit = limit
+	}
+	return opts, nil
+}
+
+// Undo reverts the most recent task change
+func (h *TaskHandler) Undo(c *gin.Context) {
+	if err := h.tasks.Undo(); err != nil {
+		taskError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Redo repeats the most recently undone task change
+func (h *TaskHandler) Redo(c *gin.Context) {
+	if err := h.tasks.Redo(); err != nil {
+		taskError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// History returns the recorded versions of a task, oldest first
+func (h *TaskHandler) History(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+
+	versions, err := h.tasks.History(id)
+	if err != nil {
+		taskError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, versions)
+}
+
+// Trash returns the deleted tasks that can still be restored
+func (h *TaskHandler) Trash(c *gin.Context) {
+	c.JSON(http.StatusOK, h.tasks.Trash())
+}
+
+// Restore takes a task out of the trash
+func (h *TaskHandler) Restore(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+
+	task, err := h.tasks.RestoreTask(id)
+	if err != nil {
+		taskError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, task)
+}
+
+// taskID parses the :id path parameter, writing a 400 response if it is invalid
+func taskID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be a positive integer"})
+		return 0, false
+	}
+	return id, true
+}
+
+// bindTaskJSON binds the request body, writing 400 for malformed JSON and 422 for invalid fields
+func bindTaskJSON(c *gin.Context, req any) bool {
+	err := c.ShouldBindJSON(req)
+	if err == nil {
+		return true
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": validationErrs.Error()})
+		return false
+	}
+	if errors.Is(err, taskmanager.ErrInvalidPriority) || errors.Is(err, taskmanager.ErrInvalidRecurrence) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return false
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON body"})
+	return false
+}
+
+// taskError maps taskmanager errors to HTTP responses
+func taskError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, taskmanager.ErrTaskNotFound),
+		errors.Is(err, taskmanager.ErrNotInTrash):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, taskmanager.ErrEmptyTitle),
+		errors.Is(err, taskmanager.ErrInvalidPriority),
+		errors.Is(err, taskmanager.ErrInvalidRecurrence),
+		errors.Is(err, taskmanager.ErrRecurrenceNeedsDueDate),
+		errors.Is(err, taskmanager.ErrParentNotFound),
+		errors.Is(err, taskmanager.ErrInvalidParent),
+		errors.Is(err, taskmanager.ErrBlockerNotFound),
+		errors.Is(err, taskmanager.ErrDependencyCycle):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, taskmanager.ErrOpenSubtasks),
+		errors.Is(err, taskmanager.ErrParentCompleted),
+		errors.Is(err, taskmanager.ErrHasSubtasks),
+		errors.Is(err, taskmanager.ErrTaskExists),
+		errors.Is(err, taskmanager.ErrNothingToUndo),
+		errors.Is(err, taskmanager.ErrNothingToRedo):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
@@ -0,0 +1,428 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"lab01/taskmanager"
+)
+
+func newTaskRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	NewTaskHandler(taskmanager.NewTaskManager()).Register(router.Group("/api/v1"))
+	return router
+}
+
+func doJSON(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestTaskCRUD(t *testing.T) {
+	router := newTaskRouter()
+
+	w := doJSON(router, http.MethodPost, "/api/v1/tasks", `{"title":"Write tests","description":"for tasks"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created taskmanager.Task
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to decode task: %v", err)
+	}
+	if created.ID != 1 || created.Title != "Write tests" {
+		t.Errorf("Unexpected created task: %+v", created)
+	}
+
+	w = doJSON(router, http.MethodPut, "/api/v1/tasks/1", `{"title":"Write more tests","description":"","done":true}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = doJSON(router, http.MethodGet, "/api/v1/tasks/1", "")
+	var got taskmanager.Task
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to decode task: %v", err)
+	}
+	if got.Title != "Write more tests" || !got.Done {
+		t.Errorf("Expected updated task, got %+v", got)
+	}
+
+	w = doJSON(router, http.MethodDelete, "/api/v1/tasks/1", "")
+	if w.Code != http.StatusOK || w.Body.String() != `{"unblocked":[]}` {
+		t.Errorf("Expected status 200 with nothing unblocked, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = doJSON(router, http.MethodGet, "/api/v1/tasks/1", "")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 after delete, got %d", w.Code)
+	}
+}
+
+func TestTaskErrors(t *testing.T) {
+	router := newTaskRouter()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{name: "empty title", method: http.MethodPost, path: "/api/v1/tasks", body: `{"title":""}`, status: http.StatusUnprocessableEntity},
+		{name: "title too long", method: http.MethodPost, path: "/api/v1/tasks", body: `{"title":"` + strings.Repeat("x", 201) + `"}`, status: http.StatusUnprocessableEntity},
+		{name: "malformed json", method: http.MethodPost, path: "/api/v1/tasks", body: `{"title":`, status: http.StatusBadRequest},
+		{name: "unknown task", method: http.MethodGet, path: "/api/v1/tasks/999", status: http.StatusNotFound},
+		{name: "invalid id", method: http.MethodGet, path: "/api/v1/tasks/abc", status: http.StatusBadRequest},
+		{name: "update unknown task", method: http.MethodPut, path: "/api/v1/tasks/999", body: `{"title":"x"}`, status: http.StatusNotFound},
+		{name: "delete unknown task", method: http.MethodDelete, path: "/api/v1/tasks/999", status: http.StatusNotFound},
+		{name: "invalid done filter", method: http.MethodGet, path: "/api/v1/tasks?done=maybe", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doJSON(router, tt.method, tt.path, tt.body)
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestTaskListFilter(t *testing.T) {
+	router := newTaskRouter()
+
+	doJSON(router, http.MethodPost, "/api/v1/tasks", `{"title":"one"}`)
+	doJSON(router, http.MethodPost, "/api/v1/tasks", `{"title":"two"}`)
+	doJSON(router, http.MethodPut, "/api/v1/tasks/2", `{"title":"two","done":true}`)
+
+	tests := []struct {
+		query    string
+		expected int
+	}{
+		{query: "", expected: 2},
+		{query: "?done=true", expected: 1},
+		{query: "?done=false", expected: 1},
+	}
+
+	for _, tt := range tests {
+		w := doJSON(router, http.MethodGet, "/api/v1/tasks"+tt.query, "")
+		var tasks []taskmanager.Task
+		if err := json.Unmarshal(w.Body.Bytes(), &tasks); err != nil {
+			t.Fatalf("Failed to decode tasks: %v", err)
+		}
+		if len(tasks) != tt.expected {
+			t.Errorf("GET /tasks%s returned %d tasks, want %d", tt.query, len(tasks), tt.expected)
+		}
+	}
+}
+
+func TestTaskPatchAndSubtasks(t *testing.T) {
+	router := newTaskRouter()
+
+	w := doJSON(router, http.MethodPost, "/api/v1/tasks", `{"title":"Release","priority":"high","tags":["ops"],"due_date":"2025-06-10T00:00:00Z"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	w = doJSON(router, http.MethodPost, "/api/v1/tasks", `{"title":"Changelog","parent_id":1}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 for sub-task, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = doJSON(router, http.MethodPatch, "/api/v1/tasks/1", `{"priority":"low"}`)
+	var patched taskmanager.Task
+	if err := json.Unmarshal(w.Body.Bytes(), &patched); err != nil {
+		t.Fatalf("Failed to decode task: %v", err)
+	}
+	if patched.Priority != taskmanager.PriorityLow || patched.Title != "Release" || len(patched.Tags) != 1 || patched.DueDate == nil {
+		t.Errorf("Expected only the priority to change, got %+v", patched)
+	}
+
+	w = doJSON(router, http.MethodGet, "/api/v1/tasks/1/subtasks", "")
+	var subtasks []taskmanager.Task
+	if err := json.Unmarshal(w.Body.Bytes(), &subtasks); err != nil || len(subtasks) != 1 {
+		t.Errorf("Expected one sub-task, got %s", w.Body.String())
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{name: "complete parent with open sub-task", method: http.MethodPatch, path: "/api/v1/tasks/1", body: `{"done":true}`, status: http.StatusConflict},
+		{name: "delete parent with sub-task", method: http.MethodDelete, path: "/api/v1/tasks/1", status: http.StatusConflict},
+		{name: "unknown priority", method: http.MethodPatch, path: "/api/v1/tasks/1", body: `{"priority":"urgent"}`, status: http.StatusUnprocessableEntity},
+		{name: "unknown parent", method: http.MethodPost, path: "/api/v1/tasks", body: `{"title":"x","parent_id":99}`, status: http.StatusUnprocessableEntity},
+		{name: "parent cycle", method: http.MethodPatch, path: "/api/v1/tasks/1", body: `{"parent_id":2}`, status: http.StatusUnprocessableEntity},
+		{name: "empty title", method: http.MethodPatch, path: "/api/v1/tasks/1", body: `{"title":""}`, status: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doJSON(router, tt.method, tt.path, tt.body)
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestTaskListQuery(t *testing.T) {
+	router := newTaskRouter()
+
+	doJSON(router, http.MethodPost, "/api/v1/tasks", `{"title":"Write report","priority":"high","tags":["work"],"due_date":"2025-06-05T00:00:00Z"}`)
+	doJSON(router, http.MethodPost, "/api/v1/tasks", `{"title":"Buy milk","priority":"low","tags":["home"]}`)
+	doJSON(router, http.MethodPost, "/api/v1/tasks", `{"title":"Review report","tags":["work"],"due_date":"2025-06-02T00:00:00Z"}`)
+
+	tests := []struct {
+		query    string
+		expected []int
+	}{
+		{query: "?q=REPORT", expected: []int{1, 3}},
+		{query: "?tag=work&sort=-due_date", expected: []int{1, 3}},
+		{query: "?priority=high&priority=low", expected: []int{1, 2}},
+		{query: "?due_before=2025-06-03T00:00:00Z", expected: []int{3}},
+		{query: "?sort=title", expected: []int{2, 3, 1}},
+	}
+
+	for _, tt := range tests {
+		w := doJSON(router, http.MethodGet, "/api/v1/tasks"+tt.query, "")
+		var tasks []taskmanager.Task
+		if err := json.Unmarshal(w.Body.Bytes(), &tasks); err != nil {
+			t.Fatalf("GET /tasks%s: failed to decode tasks: %s", tt.query, w.Body.String())
+		}
+		got := []int{}
+		for _, task := range tasks {
+			got = append(got, task.ID)
+		}
+		if !slices.Equal(got, tt.expected) {
+			t.Errorf("GET /tasks%s returned %v, want %v", tt.query, got, tt.expected)
+		}
+	}
+
+	w := doJSON(router, http.MethodGet, "/api/v1/tasks?sort=title&limit=2", "")
+	cursor := w.Header().Get(NextCursorHeader)
+	if cursor == "" {
+		t.Fatal("Expected a next cursor header")
+	}
+	w = doJSON(router, http.MethodGet, "/api/v1/tasks?sort=title&limit=2&cursor="+cursor, "")
+	var tasks []taskmanager.Task
+	json.Unmarshal(w.Body.Bytes(), &tasks)
+	if len(tasks) != 1 || tasks[0].ID != 1 || w.Header().Get(NextCursorHeader) != "" {
+		t.Errorf("Expected last page with task 1, got %s", w.Body.String())
+	}
+
+	for _, query := range []string{"?sort=colour", "?limit=0", "?limit=1000", "?cursor=bogus", "?due_after=tomorrow", "?priority=urgent"} {
+		w := doJSON(router, http.MethodGet, "/api/v1/tasks"+query, "")
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("GET /tasks%s: expected status 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestTaskRecurrence(t *testing.T) {
+	router := newTaskRouter()
+
+	w := doJSON(router, http.MethodPost, "/api/v1/tasks", `{"title":"Water plants","due_date":"2025-06-02T09:00:00Z","recurrence":"FREQ=WEEKLY;BYDAY=MO,TH"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"recurrence":"FREQ=WEEKLY;BYDAY=MO,TH"`) {
+		t.Errorf("Expected the rule in the response, got %s", w.Body.String())
+	}
+
+	doJSON(router, http.MethodPatch, "/api/v1/tasks/1", `{"done":true}`)
+	w = doJSON(router, http.MethodGet, "/api/v1/tasks/2", "")
+	var next taskmanager.Task
+	if err := json.Unmarshal(w.Body.Bytes(), &next); err != nil {
+		t.Fatalf("Failed to decode next occurrence: %s", w.Body.String())
+	}
+	if next.DueDate == nil || next.DueDate.Format("2006-01-02") != "2025-06-05" {
+		t.Errorf("Expected next occurrence on 2025-06-05, got %+v", next)
+	}
+
+	for _, body := range []string{
+		`{"title":"x","due_date":"2025-06-02T09:00:00Z","recurrence":"FREQ=YEARLY"}`,
+		`{"title":"x","recurrence":"FREQ=DAILY"}`,
+	} {
+		w := doJSON(router, http.MethodPost, "/api/v1/tasks", body)
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("POST %s: expected status 422, got %d", body, w.Code)
+		}
+	}
+}
+
+func TestTaskExportImport(t *testing.T) {
+	router := newTaskRouter()
+	doJSON(router, http.MethodPost, "/api/v1/tasks", `{"title":"Parent","tags":["home"]}`)
+	doJSON(router, http.MethodPost, "/api/v1/tasks", `{"title":"Child","parent_id":1}`)
+
+	tests := []struct {
+		format      string
+		contentType string
+	}{
+		{format: "json", contentType: "application/json; charset=utf-8"},
+		{format: "csv", contentType: "text/csv; charset=utf-8"},
+		{format: "ics", contentType: "text/calendar; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			w := doJSON(router, http.MethodGet, "/api/v1/tasks/export?format="+tt.format, "")
+			if w.Code != http.StatusOK || w.Header().Get("Content-Type") != tt.contentType {
+				t.Fatalf("Expected 200 with %s, got %d %s", tt.contentType, w.Code, w.Header().Get("Content-Type"))
+			}
+
+			target := newTaskRouter()
+			w = doJSON(target, http.MethodPost, "/api/v1/tasks/import?format="+tt.format, w.Body.String())
+			if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"created":2`) {
+				t.Fatalf("Expected 2 created, got %d: %s", w.Code, w.Body.String())
+			}
+			w = doJSON(target, http.MethodGet, "/api/v1/tasks/2", "")
+			if !strings.Contains(w.Body.String(), `"parent_id":1`) {
+				t.Errorf("Expected the child to keep its parent, got %s", w.Body.String())
+			}
+		})
+	}
+
+	w := doJSON(router, http.MethodPost, "/api/v1/tasks/import?format=csv&mode=merge", "id,title\n1,Renamed\n3,\n")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if expected := `{"created":0,"updated":1,"errors":[{"row":2,"error":"title cannot be empty"}]}`; w.Body.String() != expected {
+		t.Errorf("Expected %s, got %s", expected, w.Body.String())
+	}
+
+	for _, path := range []string{"/api/v1/tasks/export?format=xml", "/api/v1/tasks/import?format=xml", "/api/v1/tasks/import?mode=upsert"} {
+		method := http.MethodPost
+		if strings.Contains(path, "export") {
+			method = http.MethodGet
+		}
+		if w := doJSON(router, method, path, "[]"); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", path, w.Code)
+		}
+	}
+	if w := doJSON(router, http.MethodPost, "/api/v1/tasks/import", `{"title":`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for malformed JSON, got %d", w.Code)
+	}
+}
+
+func TestTaskUndoAndTrash(t *testing.T) {
+	router := newTaskRouter()
+	doJSON(router, http.MethodPost, "/api/v1/tasks", `{"title":"Keep"}`)
+	doJSON(router, http.MethodPatch, "/api/v1/tasks/1", `{"title":"Keep me"}`)
+
+	if w := doJSON(router, http.MethodPost, "/api/v1/tasks/undo", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(router, http.MethodGet, "/api/v1/tasks/1", ""); !strings.Contains(w.Body.String(), `"title":"Keep"`) {
+		t.Errorf("Expected the rename to be undone, got %s", w.Body.String())
+	}
+	if w := doJSON(router, http.MethodPost, "/api/v1/tasks/redo", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", w.Code)
+	}
+	if w := doJSON(router, http.MethodPost, "/api/v1/tasks/redo", ""); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 with nothing to redo, got %d", w.Code)
+	}
+
+	w := doJSON(router, http.MethodGet, "/api/v1/tasks/1/history", "")
+	var versions []taskmanager.TaskVersion
+	if err := json.Unmarshal(w.Body.Bytes(), &versions); err != nil {
+		t.Fatalf("Failed to decode history: %v", err)
+	}
+	actions := []taskmanager.Action{}
+	for _, version := range versions {
+		actions = append(actions, version.Action)
+	}
+	expected := []taskmanager.Action{taskmanager.ActionCreated, taskmanager.ActionUpdated, taskmanager.ActionUpdated, taskmanager.ActionUpdated}
+	if !slices.Equal(actions, expected) {
+		t.Errorf("Expected actions %v, got %v", expected, actions)
+	}
+
+	doJSON(router, http.MethodDelete, "/api/v1/tasks/1", "")
+	w = doJSON(router, http.MethodGet, "/api/v1/tasks/trash", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"title":"Keep me"`) {
+		t.Fatalf("Expected the task in the trash, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(router, http.MethodPost, "/api/v1/tasks/trash/1/restore", ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(router, http.MethodPost, "/api/v1/tasks/trash/1/restore", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a task not in the trash, got %d", w.Code)
+	}
+	if w := doJSON(router, http.MethodGet, "/api/v1/tasks/9/history", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown task, got %d", w.Code)
+	}
+}
+
+func TestTaskDependencies(t *testing.T) {
+	router := newTaskRouter()
+	doJSON(router, http.MethodPost, "/api/v1/tasks", `{"title":"Design"}`)
+	doJSON(router, http.MethodPost, "/api/v1/tasks", `{"title":"Build","blocked_by":[1]}`)
+	doJSON(router, http.MethodPost, "/api/v1/tasks", `{"title":"Ship"}`)
+
+	if w := doJSON(router, http.MethodPut, "/api/v1/tasks/3/blockers/2", ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"blocked_by":[2]`) {
+		t.Fatalf("Expected task 3 to wait for 2, got %d: %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{name: "cycle", method: http.MethodPut, path: "/api/v1/tasks/1/blockers/3", status: http.StatusUnprocessableEntity},
+		{name: "unknown blocker", method: http.MethodPut, path: "/api/v1/tasks/1/blockers/9", status: http.StatusUnprocessableEntity},
+		{name: "invalid blocker", method: http.MethodPut, path: "/api/v1/tasks/1/blockers/x", status: http.StatusBadRequest},
+		{name: "cycle in patch", method: http.MethodPatch, path: "/api/v1/tasks/1", body: `{"blocked_by":[2]}`, status: http.StatusUnprocessableEntity},
+		{name: "invalid blocker in body", method: http.MethodPost, path: "/api/v1/tasks", body: `{"title":"x","blocked_by":[0]}`, status: http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := doJSON(router, tt.method, tt.path, tt.body); w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	listed := func(path string) []int {
+		var tasks []taskmanager.Task
+		json.Unmarshal(doJSON(router, http.MethodGet, path, "").Body.Bytes(), &tasks)
+		result := []int{}
+		for _, task := range tasks {
+			result = append(result, task.ID)
+		}
+		return result
+	}
+	if got := listed("/api/v1/tasks/ready"); !slices.Equal(got, []int{1}) {
+		t.Errorf("Expected ready tasks [1], got %v", got)
+	}
+	if got := listed("/api/v1/tasks/plan"); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("Expected plan [1 2 3], got %v", got)
+	}
+	if got := listed("/api/v1/tasks/1/blocks"); !slices.Equal(got, []int{2}) {
+		t.Errorf("Expected task 1 to block [2], got %v", got)
+	}
+
+	w := doJSON(router, http.MethodDelete, "/api/v1/tasks/1", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"unblocked":[{"id":2`) {
+		t.Errorf("Expected task 2 to be unblocked, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(router, http.MethodDelete, "/api/v1/tasks/3/blockers/2", ""); w.Code != http.StatusOK || strings.Contains(w.Body.String(), "blocked_by") {
+		t.Errorf("Expected task 3 to wait for nothing, got %d: %s", w.Code, w.Body.String())
+	}
+}
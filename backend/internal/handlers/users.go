@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"lab01/user"
+)
+
+type userRequest struct {
+	Name  string `json:"name"`
+	Age   int    `json:"age"`
+	Email string `json:"email"`
+}
+
+// ValidateUser checks a user form and returns 200 with the user if it is
+// valid, or 422 listing every invalid field so a form can show them together
+func ValidateUser(c *gin.Context) {
+	var req userRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON body"})
+		return
+	}
+
+	u, err := user.NewUser(req.Name, req.Age, req.Email)
+	if err != nil {
+		userError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"name": u.Name, "age": u.Age, "email": u.Email})
+}
+
+// userError maps user validation errors to a 422 response with one entry per field
+func userError(c *gin.Context, err error) {
+	var errs user.ValidationErrors
+	if !errors.As(err, &errs) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+	c.JSON(http.StatusUnprocessableEntity, gin.H{"error": errs.Error(), "fields": errs})
+}
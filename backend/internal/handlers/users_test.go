@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidateUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/v1/users/validate", ValidateUser)
+
+	tests := []struct {
+		name     string
+		body     string
+		status   int
+		expected string
+	}{
+		{
+			name:     "valid",
+			body:     `{"name":"John Doe","age":30,"email":"john@example.com"}`,
+			status:   http.StatusOK,
+			expected: `{"age":30,"email":"john@example.com","name":"John Doe"}`,
+		},
+		{
+			name:   "every invalid field",
+			body:   `{"name":"","age":151,"email":"john@notvalid"}`,
+			status: http.StatusUnprocessableEntity,
+			expected: `{"error":"invalid name: must be between 1 and 30 characters; invalid age: must be between 0 and 150; invalid email format",` +
+				`"fields":[{"field":"name","code":"invalid_name","message":"invalid name: must be between 1 and 30 characters"},` +
+				`{"field":"age","code":"invalid_age","message":"invalid age: must be between 0 and 150"},` +
+				`{"field":"email","code":"invalid_email","message":"invalid email format"}]}`,
+		},
+		{
+			name:     "malformed",
+			body:     `{"age":"thirty"}`,
+			status:   http.StatusBadRequest,
+			expected: `{"error":"invalid JSON body"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doJSON(router, http.MethodPost, "/api/v1/users/validate", tt.body)
+			if w.Code != tt.status || w.Body.String() != tt.expected {
+				t.Errorf("Expected %d %s, got %d %s", tt.status, tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+}
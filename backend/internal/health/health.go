@@ -0,0 +1,164 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Status values reported for checks and for the whole service
+const (
+	StatusUp        = "up"
+	StatusDown      = "down"
+	StatusHealthy   = "healthy"
+	StatusDegraded  = "degraded"
+	StatusUnhealthy = "unhealthy"
+	StatusDraining  = "draining"
+)
+
+// DefaultTimeout bounds a check registered without its own timeout
+const DefaultTimeout = 2 * time.Second
+
+// Checker is implemented by components that can report whether they work
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to the Checker interface
+type CheckerFunc func(ctx context.Context) error
+
+// Check calls f(ctx)
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// CheckResult is the outcome of a single check
+type CheckResult struct {
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of running every registered check
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Healthy reports whether every critical check passed and the service is not draining
+func (r Report) Healthy() bool {
+	return r.Status != StatusUnhealthy && r.Status != StatusDraining
+}
+
+// registration is a named checker with its options
+type registration struct {
+	name     string
+	checker  Checker
+	critical bool
+	timeout  time.Duration
+}
+
+// Registry holds the checks that make up readiness
+type Registry struct {
+	mu       sync.RWMutex
+	checks   []registration
+	draining atomic.Bool
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a check; a failing critical check makes the service unhealthy,
+// a failing non-critical one only degraded. A zero timeout means DefaultTimeout.
+func (r *Registry) Register(name string, checker Checker, critical bool, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, registration{
+		name:     name,
+		checker:  checker,
+		critical: critical,
+		timeout:  timeout,
+	})
+}
+
+// StartDraining makes every following Run report the service as draining
+func (r *Registry) StartDraining() {
+	r.draining.Store(true)
+}
+
+// Run executes every check in parallel, each under its own timeout
+func (r *Registry) Run(ctx context.Context) Report {
+	if r.draining.Load() {
+		return Report{Status: StatusDraining, Checks: map[string]CheckResult{}}
+	}
+
+	r.mu.RLock()
+	checks := make([]registration, len(r.checks))
+	copy(checks, r.checks)
+	r.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check registration) {
+			defer wg.Done()
+			results[i] = runCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusHealthy, Checks: make(map[string]CheckResult, len(checks))}
+	for i, check := range checks {
+		result := results[i]
+		report.Checks[check.name] = result
+
+		if result.Status == StatusDown {
+			if check.critical {
+				report.Status = StatusUnhealthy
+			} else if report.Status == StatusHealthy {
+				report.Status = StatusDegraded
+			}
+		}
+	}
+	return report
+}
+
+// runCheck runs one check and converts its outcome to a CheckResult
+func runCheck(ctx context.Context, check registration) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, check.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- check.checker.Check(ctx)
+	}()
+
+	// A checker that ignores ctx must not hold up the whole report
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := CheckResult{
+		Status:    StatusUp,
+		Critical:  check.critical,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,102 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunHealthy(t *testing.T) {
+	r := NewRegistry()
+	r.Register("db", CheckerFunc(func(ctx context.Context) error { return nil }), true, 0)
+	r.Register("cache", CheckerFunc(func(ctx context.Context) error { return nil }), false, 0)
+
+	report := r.Run(context.Background())
+
+	if report.Status != StatusHealthy {
+		t.Errorf("Expected status %s, got %s", StatusHealthy, report.Status)
+	}
+	if len(report.Checks) != 2 {
+		t.Errorf("Expected 2 check results, got %d", len(report.Checks))
+	}
+	if report.Checks["db"].Status != StatusUp {
+		t.Errorf("Expected db to be up, got %s", report.Checks["db"].Status)
+	}
+}
+
+func TestRunStatuses(t *testing.T) {
+	failing := CheckerFunc(func(ctx context.Context) error { return errors.New("connection refused") })
+	passing := CheckerFunc(func(ctx context.Context) error { return nil })
+
+	tests := []struct {
+		name     string
+		critical bool
+		expected string
+		healthy  bool
+	}{
+		{name: "critical failure", critical: true, expected: StatusUnhealthy, healthy: false},
+		{name: "non-critical failure", critical: false, expected: StatusDegraded, healthy: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			r.Register("ok", passing, true, 0)
+			r.Register("broken", failing, tt.critical, 0)
+
+			report := r.Run(context.Background())
+
+			if report.Status != tt.expected {
+				t.Errorf("Expected status %s, got %s", tt.expected, report.Status)
+			}
+			if report.Healthy() != tt.healthy {
+				t.Errorf("Expected Healthy() %v, got %v", tt.healthy, report.Healthy())
+			}
+			if report.Checks["broken"].Error != "connection refused" {
+				t.Errorf("Expected error message in result, got %q", report.Checks["broken"].Error)
+			}
+		})
+	}
+}
+
+func TestRunTimeoutAndParallelism(t *testing.T) {
+	hang := CheckerFunc(func(ctx context.Context) error {
+		// Ignores ctx on purpose
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	r := NewRegistry()
+	r.Register("slow1", hang, true, 50*time.Millisecond)
+	r.Register("slow2", hang, true, 50*time.Millisecond)
+
+	start := time.Now()
+	report := r.Run(context.Background())
+	elapsed := time.Since(start)
+
+	if elapsed > 500*time.Millisecond {
+		t.Errorf("Expected checks to time out in parallel, took %v", elapsed)
+	}
+	if report.Status != StatusUnhealthy {
+		t.Errorf("Expected status %s, got %s", StatusUnhealthy, report.Status)
+	}
+	if report.Checks["slow1"].Error != context.DeadlineExceeded.Error() {
+		t.Errorf("Expected deadline exceeded error, got %q", report.Checks["slow1"].Error)
+	}
+}
+
+func TestRunDraining(t *testing.T) {
+	r := NewRegistry()
+	r.Register("db", CheckerFunc(func(ctx context.Context) error { return nil }), true, 0)
+	r.StartDraining()
+
+	report := r.Run(context.Background())
+
+	if report.Status != StatusDraining {
+		t.Errorf("Expected status %s, got %s", StatusDraining, report.Status)
+	}
+	if report.Healthy() {
+		t.Error("Expected draining service to be unhealthy")
+	}
+}
@@ -0,0 +1,22 @@
+package logging
+
+import (
+	"io"
+	"log/slog"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/config"
+)
+
+// New creates a logger writing to w in the level and format set in cfg
+func New(cfg *config.Config, w io.Writer) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: cfg.LogLevel}
+
+	var handler slog.Handler
+	if cfg.LogFormat == "text" {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+
+	return slog.New(handler).With("service", "sum25-go-flutter-course-backend")
+}
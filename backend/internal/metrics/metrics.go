@@ -0,0 +1,252 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ContentType is the Prometheus text exposition format content type
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the latency histogram upper bounds in seconds
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// requestKey identifies one series of the HTTP metrics
+type requestKey struct {
+	method string
+	route  string
+	status string
+}
+
+// histogram is a cumulative-on-export latency histogram
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// Registry collects HTTP and Go runtime metrics
+type Registry struct {
+	mu        sync.Mutex
+	buckets   []float64
+	requests  map[requestKey]uint64
+	durations map[requestKey]*histogram
+	inFlight  atomic.Int64
+}
+
+// NewRegistry creates a registry using DefaultBuckets
+func NewRegistry() *Registry {
+	return &Registry{
+		buckets:   DefaultBuckets,
+		requests:  make(map[requestKey]uint64),
+		durations: make(map[requestKey]*histogram),
+	}
+}
+
+// RequestStarted increments the in-flight gauge
+func (r *Registry) RequestStarted() {
+	r.inFlight.Add(1)
+}
+
+// RequestFinished decrements the in-flight gauge and records the request
+func (r *Registry) RequestFinished(method, route string, status int, elapsed time.Duration) {
+	r.inFlight.Add(-1)
+
+	key := requestKey{method: MethodLabel(method), route: route, status: StatusClass(status)}
+	seconds := elapsed.Seconds()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.requests[key]++
+
+	h, ok := r.durations[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(r.buckets))}
+		r.durations[key] = h
+	}
+	h.count++
+	h.sum += seconds
+	for i, bound := range r.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+}
+
+// StatusClass maps a status code to its class label, such as "2xx"
+func StatusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
+
+// MethodLabel maps a request method to its label, folding anything outside the
+// standard methods into "OTHER" so clients cannot create new series at will
+func MethodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "OTHER"
+}
+
+// Handler serves the metrics in the Prometheus text exposition format
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		r.WriteTo(w)
+	})
+}
+
+// WriteTo writes every metric in the Prometheus text exposition format
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+
+	r.writeHTTP(cw)
+	writeRuntime(cw)
+
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+// writeHTTP writes the request counter, latency histogram and in-flight gauge
+func (r *Registry) writeHTTP(w *countingWriter) {
+	r.mu.Lock()
+	keys := make([]requestKey, 0, len(r.requests))
+	requests := make(map[requestKey]uint64, len(r.requests))
+	durations := make(map[requestKey]histogram, len(r.durations))
+	for key, count := range r.requests {
+		keys = append(keys, key)
+		requests[key] = count
+		h := r.durations[key]
+		durations[key] = histogram{counts: append([]uint64(nil), h.counts...), count: h.count, sum: h.sum}
+	}
+	r.mu.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.status < b.status
+	})
+
+	w.header("http_requests_total", "counter", "Total number of HTTP requests.")
+	for _, key := range keys {
+		w.printf("http_requests_total%s %d\n", key.labels(), requests[key])
+	}
+
+	w.header("http_request_duration_seconds", "histogram", "HTTP request latency in seconds.")
+	for _, key := range keys {
+		h := durations[key]
+		labels := key.labels()
+		base := labels[1 : len(labels)-1]
+
+		var cumulative uint64
+		for i, bound := range r.buckets {
+			cumulative += h.counts[i]
+			w.printf("http_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", base, formatFloat(bound), cumulative)
+		}
+		w.printf("http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", base, h.count)
+		w.printf("http_request_duration_seconds_sum%s %s\n", labels, formatFloat(h.sum))
+		w.printf("http_request_duration_seconds_count%s %d\n", labels, h.count)
+	}
+
+	w.header("http_requests_in_flight", "gauge", "Number of HTTP requests currently being served.")
+	w.printf("http_requests_in_flight %d\n", r.inFlight.Load())
+}
+
+// writeRuntime writes Go runtime statistics
+func writeRuntime(w *countingWriter) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	w.header("go_info", "gauge", "Information about the Go environment.")
+	w.printf("go_info{version=%q} 1\n", runtime.Version())
+
+	w.header("go_goroutines", "gauge", "Number of goroutines that currently exist.")
+	w.printf("go_goroutines %d\n", runtime.NumGoroutine())
+
+	gauges := []struct {
+		name  string
+		help  string
+		value uint64
+	}{
+		{"go_memstats_alloc_bytes", "Number of bytes allocated and still in use.", mem.Alloc},
+		{"go_memstats_heap_inuse_bytes", "Number of heap bytes that are in use.", mem.HeapInuse},
+		{"go_memstats_heap_objects", "Number of allocated objects.", mem.HeapObjects},
+		{"go_memstats_sys_bytes", "Number of bytes obtained from the system.", mem.Sys},
+	}
+	for _, g := range gauges {
+		w.header(g.name, "gauge", g.help)
+		w.printf("%s %d\n", g.name, g.value)
+	}
+
+	w.header("go_memstats_alloc_bytes_total", "counter", "Total number of bytes allocated, even if freed.")
+	w.printf("go_memstats_alloc_bytes_total %d\n", mem.TotalAlloc)
+
+	w.header("go_gc_cycles_total", "counter", "Number of completed GC cycles.")
+	w.printf("go_gc_cycles_total %d\n", mem.NumGC)
+
+	w.header("go_gc_pause_seconds_total", "counter", "Total time spent in GC stop-the-world pauses.")
+	w.printf("go_gc_pause_seconds_total %s\n", formatFloat(time.Duration(mem.PauseTotalNs).Seconds()))
+}
+
+// labels renders the key as a Prometheus label set
+func (k requestKey) labels() string {
+	return fmt.Sprintf(`{method="%s",route="%s",status="%s"}`,
+		escapeLabel(k.method), escapeLabel(k.route), escapeLabel(k.status))
+}
+
+// labelEscaper escapes label values as the exposition format requires
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel escapes a label value
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+// formatFloat formats a sample value
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// countingWriter remembers the first error and the number of bytes written
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+// printf writes a formatted line unless an earlier write failed
+func (c *countingWriter) printf(format string, args ...any) {
+	if c.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(c.w, format, args...)
+	c.n += int64(n)
+	c.err = err
+}
+
+// header writes the HELP and TYPE lines of a metric family
+func (c *countingWriter) header(name, kind, help string) {
+	c.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatusClass(t *testing.T) {
+	tests := []struct {
+		status   int
+		expected string
+	}{
+		{200, "2xx"},
+		{204, "2xx"},
+		{404, "4xx"},
+		{503, "5xx"},
+		{0, "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := StatusClass(tt.status); got != tt.expected {
+			t.Errorf("StatusClass(%d) = %s, want %s", tt.status, got, tt.expected)
+		}
+	}
+}
+
+func TestMethodLabel(t *testing.T) {
+	tests := []struct {
+		method   string
+		expected string
+	}{
+		{"GET", "GET"},
+		{"DELETE", "DELETE"},
+		{"FOO", "OTHER"},
+		{"get", "OTHER"},
+		{"", "OTHER"},
+	}
+
+	for _, tt := range tests {
+		if got := MethodLabel(tt.method); got != tt.expected {
+			t.Errorf("MethodLabel(%q) = %s, want %s", tt.method, got, tt.expected)
+		}
+	}
+}
+
+func TestExposition(t *testing.T) {
+	r := NewRegistry()
+
+	r.RequestStarted()
+	r.RequestFinished("GET", "/api/v1/tasks/:id", 200, 3*time.Millisecond)
+	r.RequestStarted()
+	r.RequestFinished("GET", "/api/v1/tasks/:id", 201, 300*time.Millisecond)
+	r.RequestStarted()
+	r.RequestFinished("GET", "/api/v1/tasks/:id", 404, time.Millisecond)
+	r.RequestStarted()
+	r.RequestFinished("FOO", "unmatched", 404, time.Millisecond)
+	r.RequestStarted() // still in flight
+
+	w := httptest.NewRecorder()
+	r.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if ct := w.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("Expected content type %q, got %q", ContentType, ct)
+	}
+
+	body := w.Body.String()
+	expected := []string{
+		"# TYPE http_requests_total counter",
+		`http_requests_total{method="GET",route="/api/v1/tasks/:id",status="2xx"} 2`,
+		`http_requests_total{method="GET",route="/api/v1/tasks/:id",status="4xx"} 1`,
+		"# TYPE http_request_duration_seconds histogram",
+		`http_request_duration_seconds_bucket{method="GET",route="/api/v1/tasks/:id",status="2xx",le="0.005"} 1`,
+		`http_request_duration_seconds_bucket{method="GET",route="/api/v1/tasks/:id",status="2xx",le="0.25"} 1`,
+		`http_request_duration_seconds_bucket{method="GET",route="/api/v1/tasks/:id",status="2xx",le="0.5"} 2`,
+		`http_request_duration_seconds_bucket{method="GET",route="/api/v1/tasks/:id",status="2xx",le="+Inf"} 2`,
+		`http_request_duration_seconds_count{method="GET",route="/api/v1/tasks/:id",status="2xx"} 2`,
+		`http_requests_total{method="OTHER",route="unmatched",status="4xx"} 1`,
+		"http_requests_in_flight 1",
+		"# TYPE go_goroutines gauge",
+		"go_memstats_alloc_bytes ",
+		"go_gc_cycles_total ",
+	}
+	for _, line := range expected {
+		if !strings.Contains(body, line) {
+			t.Errorf("Expected exposition to contain %q\n%s", line, body)
+		}
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	got := escapeLabel("a\"b\\c\nd")
+	want := `a\"b\\c\nd`
+	if got != want {
+		t.Errorf("escapeLabel = %q, want %q", got, want)
+	}
+}
@@ -1,22 +1,121 @@
 package middleware
 
 import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/config"
+)
+
+const (
+	corsAllowHeaders  = "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With"
+	corsAllowMethods  = "POST, OPTIONS, GET, PUT, PATCH, DELETE"
+	corsExposeHeaders = "Content-Length, X-Request-ID, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, Retry-After, X-Next-Cursor"
+	corsMaxAge        = 12 * time.Hour
 )
 
-// CORS middleware to handle Cross-Origin Resource Sharing
-func CORS() gin.HandlerFunc {
+// originMatcher decides whether an origin is in the allow-list. A "*" entry
+// sets any, which opens the API to every origin but never with credentials.
+type originMatcher struct {
+	any      bool
+	exact    map[string]bool
+	suffixes []wildcardOrigin
+}
+
+// wildcardOrigin is an allow-list entry such as https://*.example.com
+type wildcardOrigin struct {
+	scheme string
+	suffix string
+}
+
+// newOriginMatcher parses the list of allowed origins
+func newOriginMatcher(origins []string) *originMatcher {
+	m := &originMatcher{exact: make(map[string]bool)}
+
+	for _, entry := range origins {
+		origin := strings.ToLower(strings.TrimRight(strings.TrimSpace(entry), "/"))
+		switch {
+		case origin == "":
+			continue
+		case origin == "*":
+			m.any = true
+		case strings.Contains(origin, "://*."):
+			scheme, host, _ := strings.Cut(origin, "://*")
+			m.suffixes = append(m.suffixes, wildcardOrigin{scheme: scheme + "://", suffix: host})
+		default:
+			m.exact[origin] = true
+		}
+	}
+
+	return m
+}
+
+// allows reports whether origin is listed explicitly and may send credentials
+func (m *originMatcher) allows(origin string) bool {
+	if origin == "" {
+		return false
+	}
+
+	origin = strings.ToLower(origin)
+	if m.exact[origin] {
+		return true
+	}
+
+	for _, w := range m.suffixes {
+		host, ok := strings.CutPrefix(origin, w.scheme)
+		// Require at least one label before the suffix so *.example.com does not match example.com
+		if ok && len(host) > len(w.suffix) && strings.HasSuffix(host, w.suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CORS middleware to handle Cross-Origin Resource Sharing for the origins in cfg.CORSOrigins
+func CORS(cfg *config.Config) gin.HandlerFunc {
+	matcher := newOriginMatcher(cfg.CORSOrigins)
+	maxAge := strconv.Itoa(int(corsMaxAge.Seconds()))
+
 	return gin.HandlerFunc(func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		header := c.Writer.Header()
+		header.Add("Vary", "Origin")
+
+		credentials := matcher.allows(origin)
+		if !credentials && (!matcher.any || origin == "") {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if credentials {
+			header.Set("Access-Control-Allow-Origin", origin)
+			header.Set("Access-Control-Allow-Credentials", "true")
+		} else {
+			// Browsers refuse credentials with a literal "*", so echoing the origin here would reopen the hole
+			header.Set("Access-Control-Allow-Origin", "*")
+		}
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
+		if preflight {
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+			header.Set("Access-Control-Allow-Headers", corsAllowHeaders)
+			header.Set("Access-Control-Allow-Methods", corsAllowMethods)
+			header.Set("Access-Control-Max-Age", maxAge)
+			c.AbortWithStatus(http.StatusNoContent)
 			return
 		}
 
+		header.Set("Access-Control-Expose-Headers", corsExposeHeaders)
 		c.Next()
 	})
 }
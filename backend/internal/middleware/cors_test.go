@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/config"
+)
+
+func newCORSRouter(origins ...string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(CORS(&config.Config{CORSOrigins: origins}))
+	router.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, "pong")
+	})
+	return router
+}
+
+func TestCORSAllowedOrigin(t *testing.T) {
+	router := newCORSRouter("http://localhost:3000", "https://*.example.com")
+
+	tests := []struct {
+		name    string
+		origin  string
+		allowed bool
+	}{
+		{name: "exact match", origin: "http://localhost:3000", allowed: true},
+		{name: "wildcard subdomain", origin: "https://app.example.com", allowed: true},
+		{name: "nested subdomain", origin: "https://a.b.example.com", allowed: true},
+		{name: "bare wildcard domain", origin: "https://example.com", allowed: false},
+		{name: "wrong scheme", origin: "http://app.example.com", allowed: false},
+		{name: "suffix lookalike", origin: "https://evilexample.com", allowed: false},
+		{name: "unknown origin", origin: "http://evil.test", allowed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			req.Header.Set("Origin", tt.origin)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("Expected status 200, got %d", w.Code)
+			}
+
+			got := w.Header().Get("Access-Control-Allow-Origin")
+			if tt.allowed && got != tt.origin {
+				t.Errorf("Expected Allow-Origin %q, got %q", tt.origin, got)
+			}
+			if !tt.allowed && got != "" {
+				t.Errorf("Expected no Allow-Origin, got %q", got)
+			}
+			if w.Header().Get("Vary") != "Origin" {
+				t.Errorf("Expected Vary: Origin, got %q", w.Header().Get("Vary"))
+			}
+		})
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	router := newCORSRouter("http://localhost:3000")
+
+	req := httptest.NewRequest(http.MethodOptions, "/ping", nil)
+	req.Header.Set("Origin", "http://localhost:3000")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", w.Code)
+	}
+	if w.Header().Get("Access-Control-Max-Age") != "43200" {
+		t.Errorf("Expected Max-Age 43200, got %q", w.Header().Get("Access-Control-Max-Age"))
+	}
+	if w.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Error("Expected Allow-Credentials to be true")
+	}
+
+	req = httptest.NewRequest(http.MethodOptions, "/ping", nil)
+	req.Header.Set("Origin", "http://evil.test")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for disallowed preflight, got %d", w.Code)
+	}
+}
+
+func TestCORSAnyOrigin(t *testing.T) {
+	router := newCORSRouter("*", "http://localhost:3000")
+
+	tests := []struct {
+		name        string
+		origin      string
+		allowOrigin string
+		credentials string
+	}{
+		{name: "listed origin keeps credentials", origin: "http://localhost:3000", allowOrigin: "http://localhost:3000", credentials: "true"},
+		{name: "other origin gets a literal wildcard", origin: "http://evil.test", allowOrigin: "*", credentials: ""},
+		{name: "no origin", origin: "", allowOrigin: "", credentials: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.allowOrigin {
+				t.Errorf("Expected Allow-Origin %q, got %q", tt.allowOrigin, got)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials"); got != tt.credentials {
+				t.Errorf("Expected Allow-Credentials %q, got %q", tt.credentials, got)
+			}
+		})
+	}
+}
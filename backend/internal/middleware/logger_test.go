@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newLoggedRouter(buf *bytes.Buffer) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+	router := gin.New()
+	router.Use(RequestID())
+	router.Use(RequestLogger(logger))
+	router.GET("/items/:id", func(c *gin.Context) {
+		c.String(http.StatusOK, RequestIDFromContext(c.Request.Context()))
+	})
+	return router
+}
+
+func TestRequestIDPropagation(t *testing.T) {
+	var buf bytes.Buffer
+	router := newLoggedRouter(&buf)
+
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "propagates valid id", incoming: "abc-123", keep: true},
+		{name: "assigns when missing", incoming: "", keep: false},
+		{name: "replaces invalid id", incoming: "has spaces in it", keep: false},
+		{name: "replaces oversized id", incoming: strings.Repeat("x", 200), keep: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			got := w.Header().Get(RequestIDHeader)
+			if got == "" {
+				t.Fatal("Expected X-Request-ID response header")
+			}
+			if tt.keep && got != tt.incoming {
+				t.Errorf("Expected request ID %q, got %q", tt.incoming, got)
+			}
+			if !tt.keep && got == tt.incoming {
+				t.Errorf("Expected a new request ID, got %q", got)
+			}
+			if w.Body.String() != got {
+				t.Errorf("Expected handler to see request ID %q, got %q", got, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequestLoggerFields(t *testing.T) {
+	var buf bytes.Buffer
+	router := newLoggedRouter(&buf)
+
+	req := httptest.NewRequest(http.MethodGet, "/items/42", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("Expected one JSON log line, got %q: %v", buf.String(), err)
+	}
+
+	expected := map[string]any{
+		"request_id": "req-1",
+		"method":     "GET",
+		"route":      "/items/:id",
+		"path":       "/items/42",
+		"status":     float64(200),
+		"bytes":      float64(len("req-1")),
+		"level":      "INFO",
+	}
+	for key, want := range expected {
+		if line[key] != want {
+			t.Errorf("Expected %s=%v, got %v", key, want, line[key])
+		}
+	}
+	if _, ok := line["latency_ms"]; !ok {
+		t.Error("Expected latency_ms field")
+	}
+	if _, ok := line["client_ip"]; !ok {
+		t.Error("Expected client_ip field")
+	}
+}
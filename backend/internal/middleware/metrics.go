@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/metrics"
+)
+
+// Metrics middleware records request counts, latency and in-flight requests
+// per route template, so path parameters do not create new series. Register
+// it before Recovery and CORS so panics and rejected requests are counted.
+func Metrics(registry *metrics.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		registry.RequestStarted()
+		// Deferred so a panic still leaves the in-flight gauge balanced
+		defer func() {
+			route := c.FullPath()
+			if route == "" {
+				route = "unmatched"
+			}
+			registry.RequestFinished(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+		}()
+
+		c.Next()
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/metrics"
+)
+
+func TestMetricsCountsPanics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	registry := metrics.NewRegistry()
+	router := gin.New()
+	router.Use(Metrics(registry))
+	router.Use(gin.Recovery())
+	router.GET("/boom", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/boom", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d", w.Code)
+	}
+
+	var out strings.Builder
+	registry.WriteTo(&out)
+	for _, line := range []string{
+		`http_requests_total{method="GET",route="/boom",status="5xx"} 1`,
+		"http_requests_in_flight 0",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("Expected exposition to contain %q\n%s", line, out.String())
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/auth"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/ratelimit"
+)
+
+// RateLimit middleware limits each client of a route group with a token bucket.
+// Clients are keyed by authenticated subject when known, otherwise by IP, and
+// buckets are scoped by group so limits for different groups do not interact.
+func RateLimit(store ratelimit.Store, group string, limit ratelimit.Limit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !limit.Enabled() {
+			c.Next()
+			return
+		}
+
+		result, err := store.Take(c.Request.Context(), group+":"+clientKey(c), limit)
+		if err != nil {
+			// Fail open: an unavailable store must not take the API down
+			slog.WarnContext(c.Request.Context(), "rate limit store failed", "group", group, "error", err)
+			c.Next()
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Reset", ceilSeconds(result.Reset))
+
+		if !result.Allowed {
+			header.Set("Retry-After", ceilSeconds(result.RetryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// clientKey identifies the caller by authenticated subject or client IP
+func clientKey(c *gin.Context) string {
+	if claims, ok := auth.ClaimsFromContext(c); ok {
+		return "sub:" + claims.Subject
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// ceilSeconds formats d as whole seconds, rounded up
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
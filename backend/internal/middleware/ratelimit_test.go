@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/ratelimit"
+)
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(RateLimit(ratelimit.NewMemoryStore(time.Minute, 100), "api", ratelimit.Every(2, time.Minute)))
+	router.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, "pong")
+	})
+
+	send := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := send("10.0.0.1"); w.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected status 200, got %d", i+1, w.Code)
+		}
+	}
+
+	w := send("10.0.0.1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "30" {
+		t.Errorf("Expected Retry-After 30, got %q", w.Header().Get("Retry-After"))
+	}
+	if w.Header().Get("RateLimit-Limit") != "2" || w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("Unexpected RateLimit headers: limit=%q remaining=%q",
+			w.Header().Get("RateLimit-Limit"), w.Header().Get("RateLimit-Remaining"))
+	}
+
+	if w := send("10.0.0.2"); w.Code != http.StatusOK {
+		t.Errorf("Expected another client to be allowed, got %d", w.Code)
+	}
+}
+
+func TestRateLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		proxies []string
+		limited bool
+	}{
+		{name: "untrusted peer", proxies: nil, limited: true},
+		{name: "trusted proxy", proxies: []string{"10.0.0.1"}, limited: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			if err := router.SetTrustedProxies(tt.proxies); err != nil {
+				t.Fatal(err)
+			}
+			router.Use(RateLimit(ratelimit.NewMemoryStore(time.Minute, 100), "api", ratelimit.Every(1, time.Minute)))
+			router.GET("/ping", func(c *gin.Context) {
+				c.String(http.StatusOK, "pong")
+			})
+
+			var last int
+			for _, forwarded := range []string{"1.1.1.1", "2.2.2.2", "3.3.3.3"} {
+				req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+				req.RemoteAddr = "10.0.0.1:1234"
+				req.Header.Set("X-Forwarded-For", forwarded)
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				last = w.Code
+			}
+
+			// Behind a trusted proxy each forwarded client has its own bucket;
+			// otherwise the header is ignored and the peer is limited
+			if limited := last == http.StatusTooManyRequests; limited != tt.limited {
+				t.Errorf("Expected limited=%v, got status %d", tt.limited, last)
+			}
+		})
+	}
+}
@@ -0,0 +1,341 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// tableName is where applied versions are recorded
+const tableName = "schema_migrations"
+
+// Predefined errors
+var (
+	ErrUnknownVersion = errors.New("unknown migration version")
+	ErrMissingDown    = errors.New("migration has no down script")
+)
+
+// Status describes a known migration and whether it has been applied
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator applies migrations to a database, one transaction per step
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	now        func() time.Time
+}
+
+// New creates a migrator for the given database and migrations
+func New(db *sql.DB, migrations []Migration) *Migrator {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	return &Migrator{
+		db:         db,
+		migrations: sorted,
+		now:        time.Now,
+	}
+}
+
+// Version returns the highest applied version, or 0 if nothing is applied
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int64
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// Status lists every known migration in version order
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		at, ok := applied[mig.Version]
+		statuses = append(statuses, Status{Migration: mig, Applied: ok, AppliedAt: at})
+	}
+	return statuses, nil
+}
+
+// Up applies every pending migration and returns how many were applied
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+		if err := m.apply(ctx, mig); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Down rolls back the given number of most recently applied migrations
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	if steps <= 0 {
+		return 0, nil
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	versions := sortedVersions(applied)
+	if steps > len(versions) {
+		steps = len(versions)
+	}
+
+	count := 0
+	for i := len(versions) - 1; i >= len(versions)-steps; i-- {
+		mig, err := m.find(versions[i])
+		if err != nil {
+			return count, err
+		}
+		if err := m.rollback(ctx, mig); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Goto migrates up or down until exactly the migrations up to version are applied
+func (m *Migrator) Goto(ctx context.Context, version int64) (int, error) {
+	if version != 0 {
+		if _, err := m.find(version); err != nil {
+			return 0, err
+		}
+	}
+	return m.migrateTo(ctx, version)
+}
+
+// Force records the migrations up to version as applied without running any SQL
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	if version != 0 {
+		if _, err := m.find(version); err != nil {
+			return err
+		}
+	}
+
+	if err := m.ensureTable(ctx); err != nil {
+		return err
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin force: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM "+tableName+" WHERE version > $1", version); err != nil {
+		return fmt.Errorf("force %d: %w", version, err)
+	}
+
+	applied, err := queryApplied(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	for _, mig := range m.migrations {
+		if mig.Version > version {
+			break
+		}
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+		if err := m.record(ctx, tx, mig.Version); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrateTo applies pending migrations up to target and rolls back those above it
+func (m *Migrator) migrateTo(ctx context.Context, target int64) (int, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+
+	versions := sortedVersions(applied)
+	for i := len(versions) - 1; i >= 0 && versions[i] > target; i-- {
+		mig, err := m.find(versions[i])
+		if err != nil {
+			return count, err
+		}
+		if err := m.rollback(ctx, mig); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	for _, mig := range m.migrations {
+		if mig.Version > target {
+			break
+		}
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+		if err := m.apply(ctx, mig); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// apply runs the up script and records the version in one transaction
+func (m *Migrator) apply(ctx context.Context, mig Migration) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin %d_%s: %w", mig.Version, mig.Name, err)
+	}
+	defer tx.Rollback()
+
+	if strings.TrimSpace(mig.Up) != "" {
+		if _, err := tx.ExecContext(ctx, mig.Up); err != nil {
+			return fmt.Errorf("apply %d_%s: %w", mig.Version, mig.Name, err)
+		}
+	}
+
+	if err := m.record(ctx, tx, mig.Version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// rollback runs the down script and removes the version in one transaction
+func (m *Migrator) rollback(ctx context.Context, mig Migration) error {
+	if strings.TrimSpace(mig.Down) == "" {
+		return fmt.Errorf("%d_%s: %w", mig.Version, mig.Name, ErrMissingDown)
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin %d_%s: %w", mig.Version, mig.Name, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, mig.Down); err != nil {
+		return fmt.Errorf("rollback %d_%s: %w", mig.Version, mig.Name, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM "+tableName+" WHERE version = $1", mig.Version); err != nil {
+		return fmt.Errorf("unrecord %d: %w", mig.Version, err)
+	}
+
+	return tx.Commit()
+}
+
+// record marks a version as applied
+func (m *Migrator) record(ctx context.Context, tx *sql.Tx, version int64) error {
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO "+tableName+" (version, applied_at) VALUES ($1, $2)",
+		version, m.now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("record %d: %w", version, err)
+	}
+	return nil
+}
+
+// applied returns every recorded version with the time it was applied
+func (m *Migrator) applied(ctx context.Context) (map[int64]time.Time, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	return queryApplied(ctx, m.db)
+}
+
+// ensureTable creates the version table if it does not exist yet
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+tableName+` (
+		version BIGINT PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", tableName, err)
+	}
+	return nil
+}
+
+// find returns the known migration with the given version
+func (m *Migrator) find(version int64) (Migration, error) {
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return mig, nil
+		}
+	}
+	return Migration{}, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// queryApplied reads the version table
+func queryApplied(ctx context.Context, q queryer) (map[int64]time.Time, error) {
+	rows, err := q.QueryContext(ctx, "SELECT version, applied_at FROM "+tableName)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", tableName, err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, fmt.Errorf("scan %s: %w", tableName, err)
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+// sortedVersions returns the keys of applied in ascending order
+func sortedVersions(applied map[int64]time.Time) []int64 {
+	versions := make([]int64, 0, len(applied))
+	for v := range applied {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions
+}
@@ -0,0 +1,259 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func writeMigration(t *testing.T, dir, name, up, down string) {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(dir, name+".up.sql"), []byte(up), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".down.sql"), []byte(down), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func tableExists(t *testing.T, db *sql.DB, name string) bool {
+	t.Helper()
+
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = $1", name).Scan(&count)
+	if err != nil {
+		t.Fatalf("Failed to query sqlite_master: %v", err)
+	}
+	return count > 0
+}
+
+func newTestMigrator(t *testing.T) (*Migrator, *sql.DB) {
+	t.Helper()
+
+	dir := t.TempDir()
+	writeMigration(t, dir, "0001_create_users",
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL);",
+		"DROP TABLE users;")
+	writeMigration(t, dir, "0002_create_posts",
+		"CREATE TABLE posts (id INTEGER PRIMARY KEY, title TEXT);\nCREATE INDEX posts_title ON posts (title);",
+		"DROP INDEX posts_title;\nDROP TABLE posts;")
+	writeMigration(t, dir, "0003_create_tags",
+		"CREATE TABLE tags (id INTEGER PRIMARY KEY);",
+		"DROP TABLE tags;")
+
+	migrations, err := LoadDir(dir)
+	if err != nil {
+		t.Fatalf("LoadDir failed: %v", err)
+	}
+
+	db := openTestDB(t)
+	return New(db, migrations), db
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	writeMigration(t, dir, "0002_second", "SELECT 2;", "SELECT -2;")
+	writeMigration(t, dir, "0001_first", "SELECT 1;", "SELECT -1;")
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("ignored"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	migrations, err := LoadDir(dir)
+	if err != nil {
+		t.Fatalf("LoadDir failed: %v", err)
+	}
+
+	if len(migrations) != 2 {
+		t.Fatalf("Expected 2 migrations, got %d", len(migrations))
+	}
+	if migrations[0].Version != 1 || migrations[0].Name != "first" {
+		t.Errorf("Expected 0001_first first, got %d_%s", migrations[0].Version, migrations[0].Name)
+	}
+	if migrations[1].Up != "SELECT 2;" || migrations[1].Down != "SELECT -2;" {
+		t.Errorf("Unexpected scripts for 0002_second: %q / %q", migrations[1].Up, migrations[1].Down)
+	}
+}
+
+func TestUpAndDown(t *testing.T) {
+	ctx := context.Background()
+	m, db := newTestMigrator(t)
+
+	n, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("Expected 3 migrations applied, got %d", n)
+	}
+	for _, table := range []string{"users", "posts", "tags"} {
+		if !tableExists(t, db, table) {
+			t.Errorf("Expected table %s to exist", table)
+		}
+	}
+
+	// Running again is a no-op
+	n, err = m.Up(ctx)
+	if err != nil || n != 0 {
+		t.Errorf("Expected second Up to apply nothing, got %d, %v", n, err)
+	}
+
+	n, err = m.Down(ctx, 1)
+	if err != nil {
+		t.Fatalf("Down failed: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 migration rolled back, got %d", n)
+	}
+	if tableExists(t, db, "tags") {
+		t.Error("Expected table tags to be dropped")
+	}
+
+	version, err := m.Version(ctx)
+	if err != nil {
+		t.Fatalf("Version failed: %v", err)
+	}
+	if version != 2 {
+		t.Errorf("Expected version 2, got %d", version)
+	}
+}
+
+func TestGoto(t *testing.T) {
+	ctx := context.Background()
+	m, db := newTestMigrator(t)
+
+	if _, err := m.Goto(ctx, 2); err != nil {
+		t.Fatalf("Goto(2) failed: %v", err)
+	}
+	if !tableExists(t, db, "posts") || tableExists(t, db, "tags") {
+		t.Error("Expected posts but not tags after Goto(2)")
+	}
+
+	if _, err := m.Goto(ctx, 1); err != nil {
+		t.Fatalf("Goto(1) failed: %v", err)
+	}
+	if tableExists(t, db, "posts") {
+		t.Error("Expected posts to be dropped after Goto(1)")
+	}
+
+	if _, err := m.Goto(ctx, 0); err != nil {
+		t.Fatalf("Goto(0) failed: %v", err)
+	}
+	if tableExists(t, db, "users") {
+		t.Error("Expected users to be dropped after Goto(0)")
+	}
+
+	if _, err := m.Goto(ctx, 42); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("Expected ErrUnknownVersion, got %v", err)
+	}
+}
+
+func TestFailedStepIsRolledBack(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	writeMigration(t, dir, "0001_ok", "CREATE TABLE ok (id INTEGER);", "DROP TABLE ok;")
+	writeMigration(t, dir, "0002_broken", "CREATE TABLE half (id INTEGER);\nNOT VALID SQL;", "DROP TABLE half;")
+
+	migrations, err := LoadDir(dir)
+	if err != nil {
+		t.Fatalf("LoadDir failed: %v", err)
+	}
+	db := openTestDB(t)
+	m := New(db, migrations)
+
+	n, err := m.Up(ctx)
+	if err == nil {
+		t.Fatal("Expected Up to fail on broken migration")
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 migration applied before failure, got %d", n)
+	}
+	if tableExists(t, db, "half") {
+		t.Error("Expected partial migration to be rolled back")
+	}
+
+	version, err := m.Version(ctx)
+	if err != nil {
+		t.Fatalf("Version failed: %v", err)
+	}
+	if version != 1 {
+		t.Errorf("Expected version 1, got %d", version)
+	}
+}
+
+func TestForceAndStatus(t *testing.T) {
+	ctx := context.Background()
+	m, db := newTestMigrator(t)
+
+	if err := m.Force(ctx, 2); err != nil {
+		t.Fatalf("Force failed: %v", err)
+	}
+	if tableExists(t, db, "users") {
+		t.Error("Force should not run any SQL")
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	want := []bool{true, true, false}
+	for i, s := range statuses {
+		if s.Applied != want[i] {
+			t.Errorf("Migration %d: expected applied=%v, got %v", s.Version, want[i], s.Applied)
+		}
+		if s.Applied && s.AppliedAt.IsZero() {
+			t.Errorf("Migration %d: AppliedAt should not be zero", s.Version)
+		}
+	}
+
+	if err := m.Force(ctx, 1); err != nil {
+		t.Fatalf("Force failed: %v", err)
+	}
+	version, err := m.Version(ctx)
+	if err != nil {
+		t.Fatalf("Version failed: %v", err)
+	}
+	if version != 1 {
+		t.Errorf("Expected version 1 after Force(1), got %d", version)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+
+	up, down, err := Create(dir, "add_users")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if filepath.Base(up) != "0001_add_users.up.sql" || filepath.Base(down) != "0001_add_users.down.sql" {
+		t.Errorf("Unexpected file names %s, %s", up, down)
+	}
+
+	up, _, err = Create(dir, "add_posts")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if filepath.Base(up) != "0002_add_posts.up.sql" {
+		t.Errorf("Expected next version 0002, got %s", filepath.Base(up))
+	}
+
+	if _, _, err := Create(dir, "bad name;"); err == nil {
+		t.Error("Expected error for invalid name")
+	}
+}
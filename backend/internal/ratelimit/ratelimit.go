@@ -0,0 +1,142 @@
+package ratelimit
+
+import (
+	"container/list"
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit is a token bucket: Burst tokens at most, refilled at Rate tokens per second
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Every returns a limit of n requests per period, allowing all n in a burst
+func Every(n int, period time.Duration) Limit {
+	if n <= 0 || period <= 0 {
+		return Limit{}
+	}
+	return Limit{Rate: float64(n) / period.Seconds(), Burst: n}
+}
+
+// Enabled reports whether the limit allows anything at all
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Result is the outcome of taking a token
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // until one token is available, when not allowed
+	Reset      time.Duration // until the bucket is full again
+}
+
+// Store keeps token buckets; implementations must be safe for concurrent use
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// bucket is the state of one client's tokens
+type bucket struct {
+	key    string
+	tokens float64
+	last   time.Time
+}
+
+// MemoryStore is an in-process Store that evicts idle buckets and holds at
+// most maxBuckets, dropping the least recently used one to make room
+type MemoryStore struct {
+	mu         sync.Mutex
+	buckets    map[string]*list.Element
+	order      *list.List // of *bucket, most recently used first
+	idleTTL    time.Duration
+	maxBuckets int
+	lastSweep  time.Time
+	now        func() time.Time
+}
+
+// NewMemoryStore creates a store that forgets buckets untouched for idleTTL.
+// A bucket idle that long is usually full anyway, so forgetting it loses nothing.
+// maxBuckets bounds memory however many distinct keys arrive within idleTTL.
+func NewMemoryStore(idleTTL time.Duration, maxBuckets int) *MemoryStore {
+	return &MemoryStore{
+		buckets:    make(map[string]*list.Element),
+		order:      list.New(),
+		idleTTL:    idleTTL,
+		maxBuckets: maxBuckets,
+		now:        time.Now,
+	}
+}
+
+// Take removes one token from the bucket for key if one is available
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.maybeSweep(now)
+
+	var b *bucket
+	if elem, ok := s.buckets[key]; ok {
+		s.order.MoveToFront(elem)
+		b = elem.Value.(*bucket)
+	} else {
+		if s.order.Len() >= s.maxBuckets {
+			s.remove(s.order.Back())
+		}
+		b = &bucket{key: key, tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = s.order.PushFront(b)
+	}
+
+	elapsed := now.Sub(b.last).Seconds()
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+	b.last = now
+
+	result := Result{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - b.tokens) / limit.Rate)
+	}
+
+	result.Remaining = int(b.tokens)
+	result.Reset = secondsToDuration((float64(limit.Burst) - b.tokens) / limit.Rate)
+	return result, nil
+}
+
+// Len returns the number of buckets currently held
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buckets)
+}
+
+// maybeSweep evicts idle buckets at most once per idleTTL. Callers must hold s.mu.
+func (s *MemoryStore) maybeSweep(now time.Time) {
+	if now.Sub(s.lastSweep) < s.idleTTL {
+		return
+	}
+	s.lastSweep = now
+
+	// Buckets are ordered by last use, so the idle ones are all at the back
+	for elem := s.order.Back(); elem != nil && now.Sub(elem.Value.(*bucket).last) >= s.idleTTL; elem = s.order.Back() {
+		s.remove(elem)
+	}
+}
+
+// remove drops the bucket held in elem. Callers must hold s.mu.
+func (s *MemoryStore) remove(elem *list.Element) {
+	delete(s.buckets, elem.Value.(*bucket).key)
+	s.order.Remove(elem)
+}
+
+// secondsToDuration converts fractional seconds to a duration
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
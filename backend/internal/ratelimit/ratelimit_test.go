@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func newTestStore(idleTTL time.Duration) (*MemoryStore, *time.Time) {
+	s := NewMemoryStore(idleTTL, 1000)
+	clock := time.Unix(1_700_000_000, 0)
+	s.now = func() time.Time { return clock }
+	return s, &clock
+}
+
+func TestTakeBurstAndRefill(t *testing.T) {
+	ctx := context.Background()
+	s, clock := newTestStore(time.Hour)
+	limit := Every(3, 3*time.Second) // one token per second, burst of 3
+
+	for i := 0; i < 3; i++ {
+		result, _ := s.Take(ctx, "client", limit)
+		if !result.Allowed {
+			t.Fatalf("Request %d should be allowed", i+1)
+		}
+		if result.Remaining != 2-i {
+			t.Errorf("Request %d: expected %d remaining, got %d", i+1, 2-i, result.Remaining)
+		}
+	}
+
+	result, _ := s.Take(ctx, "client", limit)
+	if result.Allowed {
+		t.Fatal("Fourth request should be rejected")
+	}
+	if result.RetryAfter != time.Second {
+		t.Errorf("Expected RetryAfter 1s, got %v", result.RetryAfter)
+	}
+	if result.Reset != 3*time.Second {
+		t.Errorf("Expected Reset 3s, got %v", result.Reset)
+	}
+
+	*clock = clock.Add(time.Second)
+	if result, _ := s.Take(ctx, "client", limit); !result.Allowed {
+		t.Error("Request after refill should be allowed")
+	}
+
+	// Other clients have their own bucket
+	if result, _ := s.Take(ctx, "other", limit); !result.Allowed || result.Remaining != 2 {
+		t.Errorf("Expected a fresh bucket for another client, got %+v", result)
+	}
+}
+
+func TestIdleBucketsAreEvicted(t *testing.T) {
+	ctx := context.Background()
+	s, clock := newTestStore(time.Minute)
+	limit := Every(10, time.Second)
+
+	for i := 0; i < 100; i++ {
+		s.Take(ctx, fmt.Sprintf("client-%d", i), limit)
+	}
+	if s.Len() != 100 {
+		t.Fatalf("Expected 100 buckets, got %d", s.Len())
+	}
+
+	*clock = clock.Add(2 * time.Minute)
+	s.Take(ctx, "fresh", limit)
+
+	if s.Len() != 1 {
+		t.Errorf("Expected idle buckets to be evicted, %d remain", s.Len())
+	}
+}
+
+func TestBucketCountIsBounded(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(time.Hour, 3)
+	limit := Every(1, time.Hour)
+
+	s.Take(ctx, "a", limit)
+	s.Take(ctx, "b", limit)
+	s.Take(ctx, "c", limit)
+	s.Take(ctx, "a", limit) // a is now the most recently used
+
+	for i := 0; i < 10; i++ {
+		s.Take(ctx, fmt.Sprintf("spoofed-%d", i), limit)
+	}
+	if s.Len() != 3 {
+		t.Errorf("Expected at most 3 buckets, got %d", s.Len())
+	}
+
+	s = NewMemoryStore(time.Hour, 3)
+	s.Take(ctx, "a", limit)
+	s.Take(ctx, "b", limit)
+	s.Take(ctx, "c", limit)
+	s.Take(ctx, "a", limit)
+	s.Take(ctx, "d", limit) // evicts b, the least recently used
+
+	if result, _ := s.Take(ctx, "a", limit); result.Allowed {
+		t.Error("Expected a to keep its empty bucket")
+	}
+	if result, _ := s.Take(ctx, "b", limit); !result.Allowed {
+		t.Error("Expected b to have been evicted and get a fresh bucket")
+	}
+}
+
+func TestEvery(t *testing.T) {
+	if Every(0, time.Minute).Enabled() {
+		t.Error("Expected zero requests to disable the limit")
+	}
+
+	limit := Every(60, time.Minute)
+	if limit.Rate != 1 || limit.Burst != 60 {
+		t.Errorf("Expected rate 1/s with burst 60, got %+v", limit)
+	}
+}
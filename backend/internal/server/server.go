@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/config"
+)
+
+// Drainer is told when the server starts shutting down, so readiness can fail
+// before connections are closed
+type Drainer interface {
+	StartDraining()
+}
+
+// Server wraps http.Server with the timeouts, TLS and shutdown sequence from Config
+type Server struct {
+	*http.Server
+	cfg *config.Config
+}
+
+// New builds a server for handler using the timeouts and TLS files in cfg
+func New(cfg *config.Config, handler http.Handler) (*Server, error) {
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Port),
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+
+	if cfg.TLSEnabled() {
+		reloader, err := NewCertReloader(cfg.TLSCertFile, cfg.TLSKeyFile, DefaultReloadInterval)
+		if err != nil {
+			return nil, err
+		}
+		srv.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: reloader.GetCertificate,
+		}
+	}
+
+	return &Server{Server: srv, cfg: cfg}, nil
+}
+
+// ListenAndServe serves HTTPS when TLS is configured and plain HTTP otherwise
+func (s *Server) ListenAndServe() error {
+	if s.TLSConfig != nil {
+		// Certificates come from TLSConfig.GetCertificate
+		return s.Server.ListenAndServeTLS("", "")
+	}
+	return s.Server.ListenAndServe()
+}
+
+// Drain fails readiness, waits for load balancers to notice, then shuts down
+// gracefully within the configured shutdown timeout
+func (s *Server) Drain(drainer Drainer) error {
+	drainer.StartDraining()
+	s.SetKeepAlivesEnabled(false)
+
+	if s.cfg.DrainDelay > 0 {
+		slog.Info("draining before shutdown", "delay", s.cfg.DrainDelay.String())
+		time.Sleep(s.cfg.DrainDelay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	defer cancel()
+	return s.Shutdown(ctx)
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeSelfSigned writes a self-signed certificate for commonName to certFile and keyFile
+func writeSelfSigned(t *testing.T, certFile, keyFile, commonName string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func commonName(t *testing.T, r *CertReloader) string {
+	t.Helper()
+
+	cert, err := r.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("GetCertificate failed: %v", err)
+	}
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.Subject.CommonName
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	writeSelfSigned(t, certFile, keyFile, "first")
+
+	r, err := NewCertReloader(certFile, keyFile, time.Minute)
+	if err != nil {
+		t.Fatalf("NewCertReloader failed: %v", err)
+	}
+	clock := time.Now()
+	r.now = func() time.Time { return clock }
+
+	if name := commonName(t, r); name != "first" {
+		t.Errorf("Expected first certificate, got %s", name)
+	}
+
+	// Replace the files with a newer pair
+	writeSelfSigned(t, certFile, keyFile, "second")
+	future := time.Now().Add(time.Hour)
+	os.Chtimes(certFile, future, future)
+	os.Chtimes(keyFile, future, future)
+
+	if name := commonName(t, r); name != "first" {
+		t.Errorf("Expected no reload before the interval, got %s", name)
+	}
+
+	clock = clock.Add(2 * time.Minute)
+	if name := commonName(t, r); name != "second" {
+		t.Errorf("Expected reloaded certificate, got %s", name)
+	}
+
+	// A broken pair keeps the previous certificate
+	if err := os.WriteFile(certFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	later := future.Add(time.Hour)
+	os.Chtimes(certFile, later, later)
+
+	clock = clock.Add(2 * time.Minute)
+	if name := commonName(t, r); name != "second" {
+		t.Errorf("Expected previous certificate after failed reload, got %s", name)
+	}
+}
+
+func TestNewCertReloaderMissingFiles(t *testing.T) {
+	if _, err := NewCertReloader("missing.crt", "missing.key", time.Minute); err == nil {
+		t.Error("Expected error for missing files, got none")
+	}
+}
@@ -0,0 +1,10 @@
+package version
+
+// Build information, injected at build time with
+//
+//	go build -ldflags "-X github.com/timur-harin/sum25-go-flutter-course/backend/internal/version.Version=1.2.3 \
+//	  -X github.com/timur-harin/sum25-go-flutter-course/backend/internal/version.Commit=abc1234"
+var (
+	Version = "dev"
+	Commit  = "unknown"
+)
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS tasks_parent_id_idx;
+
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS completed_at,
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS parent_id,
+    DROP COLUMN IF EXISTS due_date,
+    DROP COLUMN IF EXISTS tags,
+    DROP COLUMN IF EXISTS priority;
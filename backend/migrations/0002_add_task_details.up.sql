@@ -0,0 +1,14 @@
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS priority SMALLINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tags TEXT NOT NULL DEFAULT '[]',
+    ADD COLUMN IF NOT EXISTS due_date TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS parent_id BIGINT REFERENCES tasks (id),
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS completed_at TIMESTAMPTZ;
+
+UPDATE tasks SET updated_at = created_at WHERE updated_at IS NULL;
+UPDATE tasks SET completed_at = created_at WHERE done AND completed_at IS NULL;
+
+ALTER TABLE tasks ALTER COLUMN updated_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS tasks_parent_id_idx ON tasks (parent_id);
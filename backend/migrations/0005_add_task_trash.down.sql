@@ -0,0 +1,3 @@
+DELETE FROM tasks WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS deleted_at;
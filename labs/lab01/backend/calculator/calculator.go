@@ -2,6 +2,7 @@ package calculator
 
 import (
 	"errors"
+	"strconv"
 )
 
 // ErrDivisionByZero is returned when attempting to divide by zero
@@ -9,36 +10,33 @@ var ErrDivisionByZero = errors.New("division by zero")
 
 // Add adds two float64 numbers
 func Add(a, b float64) float64 {
-	// TODO: Implement this function
-	return 0
+	return a + b
 }
 
 // Subtract subtracts b from a
 func Subtract(a, b float64) float64 {
-	// TODO: Implement this function
-	return 0
+	return a - b
 }
 
 // Multiply multiplies two float64 numbers
 func Multiply(a, b float64) float64 {
-	// TODO: Implement this function
-	return 0
+	return a * b
 }
 
 // Divide divides a by b, returns an error if b is zero
 func Divide(a, b float64) (float64, error) {
-	// TODO: Implement this function
-	return 0, nil
+	if b == 0 {
+		return 0, ErrDivisionByZero
+	}
+	return a / b, nil
 }
 
 // StringToFloat converts a string to float64
 func StringToFloat(s string) (float64, error) {
-	// TODO: Implement this function
-	return 0, nil
+	return strconv.ParseFloat(s, 64)
 }
 
 // FloatToString converts a float64 to string with specified precision
 func FloatToString(f float64, precision int) string {
-	// TODO: Implement this function
-	return ""
+	return strconv.FormatFloat(f, 'f', precision, 64)
 }
@@ -0,0 +1,223 @@
+package calculator
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxExponent bounds the exponent StringToDecimal accepts, so that "1e999999999"
+// cannot make it allocate a billion digits
+const maxExponent = 10000
+
+// RoundingMode says how Divide and Round drop digits
+type RoundingMode int
+
+// Rounding modes
+const (
+	// RoundHalfEven rounds to the nearest value and ties to the even digit
+	RoundHalfEven RoundingMode = iota
+	// RoundHalfUp rounds to the nearest value and ties away from zero
+	RoundHalfUp
+	// RoundDown drops the extra digits, rounding toward zero
+	RoundDown
+)
+
+// Decimal is an exact base-10 number: an arbitrary-precision integer scaled
+// down by a power of ten. Its scale, the number of digits after the decimal
+// point, is kept through formatting, so "1.50" stays "1.50". The zero value
+// is 0. Decimals are immutable and safe to copy.
+type Decimal struct {
+	coef  *big.Int
+	scale int
+}
+
+// NewDecimal returns unscaled × 10^-scale, e.g. NewDecimal(1999, 2) is 19.99.
+// A negative scale is treated as zero.
+func NewDecimal(unscaled int64, scale int) Decimal {
+	return Decimal{coef: big.NewInt(unscaled), scale: max(scale, 0)}
+}
+
+// StringToDecimal parses a decimal such as "-123.45" or "1.5e3" exactly. Like
+// StringToFloat it rejects empty and malformed input with an error wrapping
+// strconv.ErrSyntax, and exponents too large to represent with strconv.ErrRange.
+func StringToDecimal(s string) (Decimal, error) {
+	mantissa, exponent := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		mantissa = s[:i]
+		exp, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+				return Decimal{}, parseError(s, strconv.ErrRange)
+			}
+			return Decimal{}, parseError(s, strconv.ErrSyntax)
+		}
+		if exp > maxExponent || exp < -maxExponent {
+			return Decimal{}, parseError(s, strconv.ErrRange)
+		}
+		exponent = exp
+	}
+
+	sign := ""
+	if mantissa != "" && (mantissa[0] == '+' || mantissa[0] == '-') {
+		sign, mantissa = mantissa[:1], mantissa[1:]
+	}
+	whole, frac, _ := strings.Cut(mantissa, ".")
+	digits := whole + frac
+	if digits == "" || strings.TrimLeft(digits, "0123456789") != "" {
+		return Decimal{}, parseError(s, strconv.ErrSyntax)
+	}
+
+	coef, _ := new(big.Int).SetString(sign+digits, 10)
+	return Decimal{coef: coef, scale: len(frac)}.shift(exponent), nil
+}
+
+func parseError(s string, err error) error {
+	return fmt.Errorf("calculator: parsing %q: %w", s, err)
+}
+
+// String formats d in plain notation with exactly its scale digits after the
+// point; StringToDecimal(d.String()) gives back d
+func (d Decimal) String() string {
+	digits := d.int().String()
+	sign := ""
+	if digits[0] == '-' {
+		sign, digits = "-", digits[1:]
+	}
+	if d.scale == 0 {
+		return sign + digits
+	}
+	if len(digits) <= d.scale {
+		digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+	}
+	point := len(digits) - d.scale
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// Scale returns the number of digits after the decimal point
+func (d Decimal) Scale() int {
+	return d.scale
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of d
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+// Cmp compares d and e numerically and returns -1, 0 or +1; 1.5 and 1.50 are equal
+func (d Decimal) Cmp(e Decimal) int {
+	a, b := align(d, e)
+	return a.Cmp(b)
+}
+
+// Float64 returns the float64 nearest to d
+func (d Decimal) Float64() float64 {
+	f, _ := new(big.Rat).SetFrac(d.int(), pow10(d.scale)).Float64()
+	return f
+}
+
+// Add returns d + e exactly, at the larger of the two scales
+func (d Decimal) Add(e Decimal) Decimal {
+	a, b := align(d, e)
+	return Decimal{coef: a.Add(a, b), scale: max(d.scale, e.scale)}
+}
+
+// Subtract returns d - e exactly, at the larger of the two scales
+func (d Decimal) Subtract(e Decimal) Decimal {
+	a, b := align(d, e)
+	return Decimal{coef: a.Sub(a, b), scale: max(d.scale, e.scale)}
+}
+
+// Multiply returns d × e exactly; the scales add up
+func (d Decimal) Multiply(e Decimal) Decimal {
+	return Decimal{coef: new(big.Int).Mul(d.int(), e.int()), scale: d.scale + e.scale}
+}
+
+// Divide returns d / e with scale digits after the point, rounded with mode.
+// Returns ErrDivisionByZero if e is zero. A negative scale is treated as zero.
+func (d Decimal) Divide(e Decimal, scale int, mode RoundingMode) (Decimal, error) {
+	if e.Sign() == 0 {
+		return Decimal{}, ErrDivisionByZero
+	}
+	scale = max(scale, 0)
+
+	// The result's coefficient is d.coef × 10^(scale - d.scale + e.scale) / e.coef
+	num, den := new(big.Int).Set(d.int()), new(big.Int).Set(e.int())
+	if k := scale - d.scale + e.scale; k >= 0 {
+		num.Mul(num, pow10(k))
+	} else {
+		den.Mul(den, pow10(-k))
+	}
+	return Decimal{coef: quo(num, den, mode), scale: scale}, nil
+}
+
+// Round returns d with exactly scale digits after the point, rounding with
+// mode if digits are dropped and padding with zeros otherwise. A negative
+// scale is treated as zero.
+func (d Decimal) Round(scale int, mode RoundingMode) Decimal {
+	scale = max(scale, 0)
+	if scale >= d.scale {
+		return d.rescale(scale)
+	}
+	return Decimal{coef: quo(d.int(), pow10(d.scale-scale), mode), scale: scale}
+}
+
+// int returns the coefficient, treating the zero value as 0
+func (d Decimal) int() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return d.coef
+}
+
+// shift multiplies d by 10^exp by moving the point, keeping the scale at
+// least zero
+func (d Decimal) shift(exp int) Decimal {
+	scale := d.scale - exp
+	if scale >= 0 {
+		return Decimal{coef: d.int(), scale: scale}
+	}
+	return Decimal{coef: new(big.Int).Mul(d.int(), pow10(-scale))}
+}
+
+// rescale returns d with a larger scale and the same value
+func (d Decimal) rescale(scale int) Decimal {
+	if scale == d.scale {
+		return d
+	}
+	return Decimal{coef: new(big.Int).Mul(d.int(), pow10(scale-d.scale)), scale: scale}
+}
+
+// align returns fresh coefficients of d and e at their common scale
+func align(d, e Decimal) (*big.Int, *big.Int) {
+	scale := max(d.scale, e.scale)
+	return new(big.Int).Set(d.rescale(scale).int()), new(big.Int).Set(e.rescale(scale).int())
+}
+
+// quo divides num by den, rounding the quotient with mode
+func quo(num, den *big.Int, mode RoundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 || mode == RoundDown {
+		return q
+	}
+
+	// Compare the remainder with half the divisor to find the nearest value
+	half := new(big.Int).Abs(r)
+	half.Lsh(half, 1)
+	c := half.Cmp(new(big.Int).Abs(den))
+	if c > 0 || (c == 0 && (mode == RoundHalfUp || q.Bit(0) == 1)) {
+		// The quotient was truncated toward zero, so round away from it
+		if num.Sign() == den.Sign() {
+			q.Add(q, big.NewInt(1))
+		} else {
+			q.Sub(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+// pow10 returns 10^n
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
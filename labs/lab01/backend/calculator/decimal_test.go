@@ -0,0 +1,155 @@
+package calculator
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func dec(t *testing.T, s string) Decimal {
+	t.Helper()
+	d, err := StringToDecimal(s)
+	if err != nil {
+		t.Fatalf("StringToDecimal(%q) failed: %v", s, err)
+	}
+	return d
+}
+
+func TestStringToDecimal(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		err      error
+	}{
+		{"integer", "42", "42", nil},
+		{"trailing zeros are kept", "1.50", "1.50", nil},
+		{"negative", "-123.45", "-123.45", nil},
+		{"explicit plus", "+0.001", "0.001", nil},
+		{"leading point", ".5", "0.5", nil},
+		{"exponent", "1.5e3", "1500", nil},
+		{"negative exponent", "-25E-4", "-0.0025", nil},
+		{"beyond float64", "12345678901234567890.123456789", "12345678901234567890.123456789", nil},
+		{"invalid input", "abc", "", strconv.ErrSyntax},
+		{"empty string", "", "", strconv.ErrSyntax},
+		{"two points", "1.2.3", "", strconv.ErrSyntax},
+		{"sign only", "-", "", strconv.ErrSyntax},
+		{"missing exponent", "1e", "", strconv.ErrSyntax},
+		{"huge exponent", "1e99999", "", strconv.ErrRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := StringToDecimal(tt.input)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("Expected %v, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got.String() != tt.expected {
+				t.Errorf("StringToDecimal(%q) = %v, want %v", tt.input, got, tt.expected)
+			}
+			if again := dec(t, got.String()); again.String() != got.String() {
+				t.Errorf("Expected %v to round-trip, got %v", got, again)
+			}
+		})
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	tests := []struct {
+		name     string
+		got      Decimal
+		expected string
+	}{
+		{"exact add", dec(t, "0.1").Add(dec(t, "0.2")), "0.3"},
+		{"add keeps the larger scale", dec(t, "1.5").Add(dec(t, "2.25")), "3.75"},
+		{"subtract", dec(t, "10").Subtract(dec(t, "0.01")), "9.99"},
+		{"subtract below zero", dec(t, "1.10").Subtract(dec(t, "2.2")), "-1.10"},
+		{"multiply", dec(t, "19.99").Multiply(dec(t, "3")), "59.97"},
+		{"multiply adds scales", dec(t, "0.1").Multiply(dec(t, "-0.25")), "-0.025"},
+		{"zero value", Decimal{}.Add(NewDecimal(1999, 2)), "19.99"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got.String() != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, tt.got)
+			}
+		})
+	}
+
+	if dec(t, "1.5").Cmp(dec(t, "1.500")) != 0 || dec(t, "-2").Cmp(dec(t, "1")) != -1 {
+		t.Error("Expected Cmp to compare values regardless of scale")
+	}
+	if f := dec(t, "0.1").Add(dec(t, "0.2")).Float64(); f != 0.3 {
+		t.Errorf("Expected 0.3, got %v", f)
+	}
+}
+
+func TestDecimalDivide(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		scale    int
+		mode     RoundingMode
+		expected string
+	}{
+		{"10", "4", 2, RoundHalfEven, "2.50"},
+		{"1", "3", 4, RoundHalfEven, "0.3333"},
+		{"2", "3", 4, RoundHalfEven, "0.6667"},
+		{"2", "3", 4, RoundDown, "0.6666"},
+		{"-2", "3", 4, RoundDown, "-0.6666"},
+		{"0.125", "1", 2, RoundHalfEven, "0.12"},
+		{"0.135", "1", 2, RoundHalfEven, "0.14"},
+		{"0.125", "1", 2, RoundHalfUp, "0.13"},
+		{"-0.125", "1", 2, RoundHalfUp, "-0.13"},
+		{"-0.125", "1", 2, RoundHalfEven, "-0.12"},
+		{"7", "-0.5", 0, RoundHalfEven, "-14"},
+		{"1.23456", "0.001", 1, RoundHalfEven, "1234.6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			got, err := dec(t, tt.a).Divide(dec(t, tt.b), tt.scale, tt.mode)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got.String() != tt.expected {
+				t.Errorf("%s / %s = %v, want %v", tt.a, tt.b, got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := dec(t, "1").Divide(dec(t, "0.00"), 2, RoundHalfEven); err != ErrDivisionByZero {
+		t.Errorf("Expected ErrDivisionByZero, got %v", err)
+	}
+}
+
+func TestDecimalRound(t *testing.T) {
+	tests := []struct {
+		input    string
+		scale    int
+		mode     RoundingMode
+		expected string
+	}{
+		{"2.5", 0, RoundHalfEven, "2"},
+		{"3.5", 0, RoundHalfEven, "4"},
+		{"2.5", 0, RoundHalfUp, "3"},
+		{"-2.5", 0, RoundHalfUp, "-3"},
+		{"2.99", 1, RoundDown, "2.9"},
+		{"1.5", 3, RoundHalfEven, "1.500"},
+		{"0", 2, RoundHalfEven, "0.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := dec(t, tt.input).Round(tt.scale, tt.mode); got.String() != tt.expected {
+				t.Errorf("Round(%s, %d) = %v, want %v", tt.input, tt.scale, got, tt.expected)
+			}
+		})
+	}
+}
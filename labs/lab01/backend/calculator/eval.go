@@ -0,0 +1,229 @@
+package calculator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Evaluation errors
+var (
+	ErrDomain       = errors.New("argument out of domain")
+	ErrOverflow     = errors.New("result out of range")
+	ErrUnknownName  = errors.New("unknown name")
+	ErrReservedName = errors.New("cannot assign to reserved name")
+)
+
+// SyntaxError reports a malformed expression. Col is the 1-based column, in
+// characters, where the problem was found.
+type SyntaxError struct {
+	Col int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at col %d", e.Msg, e.Col)
+}
+
+// EvalError reports a well-formed expression that cannot be computed, such as
+// a division by zero. Err is or wraps ErrDivisionByZero, ErrDomain,
+// ErrOverflow, ErrUnknownName, ErrReservedName or ErrTooManyVariables.
+type EvalError struct {
+	Col int
+	Err error
+}
+
+func (e *EvalError) Error() string {
+	return fmt.Sprintf("%v at col %d", e.Err, e.Col)
+}
+
+func (e *EvalError) Unwrap() error {
+	return e.Err
+}
+
+// function is a built-in function; arity -1 accepts one or more arguments
+type function struct {
+	arity int
+	call  func(args []float64) float64
+}
+
+func unary(f func(float64) float64) function {
+	return function{arity: 1, call: func(args []float64) float64 { return f(args[0]) }}
+}
+
+// logarithm wraps a logarithm so zero, where it has a pole, is a domain error
+// like negative arguments rather than an overflow
+func logarithm(f func(float64) float64) function {
+	return unary(func(x float64) float64 {
+		if x <= 0 {
+			return math.NaN()
+		}
+		return f(x)
+	})
+}
+
+// functions are the built-ins available to Eval
+var functions = map[string]function{
+	"sqrt":  unary(math.Sqrt),
+	"abs":   unary(math.Abs),
+	"exp":   unary(math.Exp),
+	"ln":    logarithm(math.Log),
+	"log":   logarithm(math.Log10),
+	"log2":  logarithm(math.Log2),
+	"sin":   unary(math.Sin),
+	"cos":   unary(math.Cos),
+	"tan":   unary(math.Tan),
+	"asin":  unary(math.Asin),
+	"acos":  unary(math.Acos),
+	"atan":  unary(math.Atan),
+	"floor": unary(math.Floor),
+	"ceil":  unary(math.Ceil),
+	"round": unary(math.Round),
+	"min":   {arity: -1, call: func(args []float64) float64 { return fold(args, math.Min) }},
+	"max":   {arity: -1, call: func(args []float64) float64 { return fold(args, math.Max) }},
+}
+
+// constants are the names Eval resolves without a call
+var constants = map[string]float64{
+	"pi": math.Pi,
+	"e":  math.E,
+}
+
+func fold(args []float64, f func(a, b float64) float64) float64 {
+	result := args[0]
+	for _, arg := range args[1:] {
+		result = f(result, arg)
+	}
+	return result
+}
+
+// Eval computes an infix expression such as "2 * (3 + sqrt(16)) ^ 2".
+//
+// It supports + - * / with the usual precedence, % for the remainder, ^ for
+// powers (right-associative and binding tighter than unary minus, so -2^2 is
+// -4), parentheses, the constants pi and e, and the functions sqrt, abs, exp,
+// ln, log (base 10), log2, sin, cos, tan, asin, acos, atan, floor, ceil,
+// round, min and max. Angles are in radians.
+//
+// A malformed expression returns a *SyntaxError. Dividing by zero, including
+// raising zero to a negative power, returns an *EvalError wrapping
+// ErrDivisionByZero; results that are undefined or too large wrap ErrDomain or
+// ErrOverflow, and unknown variables and functions wrap ErrUnknownName.
+func Eval(expr string) (float64, error) {
+	n, err := parse(expr)
+	if err != nil {
+		return 0, err
+	}
+	return evaluator{}.eval(n)
+}
+
+// evaluator computes parsed expressions. Names are looked up in vars before
+// the constants.
+type evaluator struct {
+	vars map[string]float64
+}
+
+func (ev evaluator) lookup(n *identNode) (float64, error) {
+	if value, ok := ev.vars[n.name]; ok {
+		return value, nil
+	}
+	if value, ok := constants[n.name]; ok {
+		return value, nil
+	}
+	return 0, &EvalError{Col: n.col, Err: fmt.Errorf("%w '%s'", ErrUnknownName, n.name)}
+}
+
+func (ev evaluator) eval(n node) (float64, error) {
+	switch n := n.(type) {
+	case *numberNode:
+		return n.value, nil
+	case *identNode:
+		return ev.lookup(n)
+	case *unaryNode:
+		x, err := ev.eval(n.x)
+		if err != nil {
+			return 0, err
+		}
+		if n.op == "-" {
+			return -x, nil
+		}
+		return x, nil
+	case *binaryNode:
+		return ev.binary(n)
+	case *callNode:
+		return ev.call(n)
+	}
+	panic(fmt.Sprintf("calculator: unexpected node %T", n))
+}
+
+func (ev evaluator) binary(n *binaryNode) (float64, error) {
+	x, err := ev.eval(n.x)
+	if err != nil {
+		return 0, err
+	}
+	y, err := ev.eval(n.y)
+	if err != nil {
+		return 0, err
+	}
+
+	var result float64
+	switch n.op {
+	case "+":
+		result = Add(x, y)
+	case "-":
+		result = Subtract(x, y)
+	case "*":
+		result = Multiply(x, y)
+	case "/":
+		if result, err = Divide(x, y); err != nil {
+			return 0, &EvalError{Col: n.col, Err: err}
+		}
+	case "%":
+		if y == 0 {
+			return 0, &EvalError{Col: n.col, Err: ErrDivisionByZero}
+		}
+		result = math.Mod(x, y)
+	case "^":
+		if x == 0 && y < 0 {
+			return 0, &EvalError{Col: n.col, Err: ErrDivisionByZero}
+		}
+		result = math.Pow(x, y)
+	}
+	return checkResult(result, n.col)
+}
+
+func (ev evaluator) call(n *callNode) (float64, error) {
+	fn, ok := functions[n.name]
+	if !ok {
+		return 0, &EvalError{Col: n.col, Err: fmt.Errorf("%w '%s'", ErrUnknownName, n.name)}
+	}
+	switch {
+	case fn.arity == -1 && len(n.args) == 0:
+		return 0, &SyntaxError{Col: n.col, Msg: fmt.Sprintf("%s expects at least 1 argument", n.name)}
+	case fn.arity >= 0 && len(n.args) != fn.arity:
+		return 0, &SyntaxError{Col: n.col, Msg: fmt.Sprintf("%s expects %d argument(s), got %d", n.name, fn.arity, len(n.args))}
+	}
+
+	args := make([]float64, len(n.args))
+	for i, arg := range n.args {
+		value, err := ev.eval(arg)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = value
+	}
+	return checkResult(fn.call(args), n.col)
+}
+
+// checkResult turns NaN and infinities into errors. Operands are always
+// finite and poles are caught before the operation, so an infinity means the
+// result is too large.
+func checkResult(result float64, col int) (float64, error) {
+	switch {
+	case math.IsNaN(result):
+		return 0, &EvalError{Col: col, Err: ErrDomain}
+	case math.IsInf(result, 0):
+		return 0, &EvalError{Col: col, Err: ErrOverflow}
+	}
+	return result, nil
+}
@@ -0,0 +1,109 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     string
+		expected float64
+	}{
+		{"number", "42", 42},
+		{"precedence", "2 + 3 * 4", 14},
+		{"left associative", "10 - 4 - 3", 3},
+		{"parentheses", "(2 + 3) * 4", 20},
+		{"unary minus", "-3 + -(-2)", -1},
+		{"power binds tighter than minus", "-2^2", -4},
+		{"power is right associative", "2^3^2", 512},
+		{"negative exponent", "2^-1", 0.5},
+		{"remainder", "17 % 5 * 2", 4},
+		{"fractions and exponents", ".5 + 1.5e2 + 2E-1", 150.7},
+		{"functions", "sqrt(16) + abs(-2) + log(1000)", 9},
+		{"nested calls", "max(1, min(7, 3), 2) + ln(e)", 4},
+		{"trigonometry", "sin(pi / 2) + cos(0)", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Eval(tt.expr)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if math.Abs(got-tt.expected) > 1e-9 {
+				t.Errorf("Eval(%q) = %v, want %v", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"(1 + 2))", "unexpected ')' at col 8"},
+		{"sqrt(4))", "unexpected ')' at col 8"},
+		{"2 * (3 + ", "unexpected end of expression at col 10"},
+		{"", "unexpected end of expression at col 1"},
+		{"1 2", "unexpected number 2 at col 3"},
+		{"1.2.3", "unexpected number .3 at col 4"},
+		{"2 $ 3", "unexpected '$' at col 3"},
+		{"(1, 2)", "unexpected ',' at col 3"},
+		{"sqrt(1, 2)", "sqrt expects 1 argument(s), got 2 at col 1"},
+		{"max()", "max expects at least 1 argument at col 1"},
+		{"x = 1", "unexpected '=' at col 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Eval(tt.expr)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Expected a SyntaxError, got %v", err)
+			}
+			if err.Error() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, err.Error())
+			}
+		})
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected error
+		col      int
+	}{
+		{"1 / (2 - 2)", ErrDivisionByZero, 3},
+		{"5 % 0", ErrDivisionByZero, 3},
+		{"1 + sqrt(-1)", ErrDomain, 5},
+		{"exp(1000)", ErrOverflow, 1},
+		{"10 ^ 400", ErrOverflow, 4},
+		{"log(0)", ErrDomain, 1},
+		{"ln(0) + 1", ErrDomain, 1},
+		{"log2(-8)", ErrDomain, 1},
+		{"0 ^ -1", ErrDivisionByZero, 3},
+		{"2 * 0 ^ -0.5", ErrDivisionByZero, 7},
+		{"1 + x", ErrUnknownName, 5},
+		{"é + 1", ErrUnknownName, 1},
+		{"2 * foo(1)", ErrUnknownName, 5},
+		{"pi(2)", ErrUnknownName, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Eval(tt.expr)
+			if !errors.Is(err, tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, err)
+			}
+			var evalErr *EvalError
+			if !errors.As(err, &evalErr) || evalErr.Col != tt.col {
+				t.Errorf("Expected col %d, got %v", tt.col, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,363 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Locale parsing errors
+var (
+	ErrAmbiguousNumber = errors.New("ambiguous number, the separator could be a decimal point or a digit group")
+	ErrInvalidGrouping = errors.New("misplaced digit group separator")
+)
+
+// Locale says how numbers are written: the decimal separator and the
+// separator between groups of three integer digits. A space group separator
+// also accepts the no-break and narrow no-break spaces.
+type Locale struct {
+	Decimal rune
+	Group   rune
+}
+
+// Common locales
+var (
+	LocaleEN = Locale{Decimal: '.', Group: ','}
+	LocaleDE = Locale{Decimal: ',', Group: '.'}
+	LocaleFR = Locale{Decimal: ',', Group: '\u202f'}
+	LocaleRU = Locale{Decimal: ',', Group: '\u00a0'}
+	LocaleCH = Locale{Decimal: '.', Group: '\''}
+)
+
+// Parse reads a number written in l, such as "1,234.5" in LocaleEN or
+// "1 234,5" in LocaleFR. It also accepts a leading sign, an exponent as in
+// "1,5e-3", and a trailing percent sign that divides by 100. Group separators
+// must split the integer digits into groups of three; "1,23" in LocaleEN is
+// rejected with ErrInvalidGrouping rather than read as 123. Other malformed
+// input, including a decimal separator with no digits after it as in "1.", is
+// rejected with an error wrapping strconv.ErrSyntax.
+func (l Locale) Parse(s string) (float64, error) {
+	mantissa, exponent, percent, err := splitNumber(s)
+	if err != nil {
+		return 0, err
+	}
+
+	sign := ""
+	if rest, ok := cutSign(mantissa); ok {
+		sign, mantissa = "-", rest
+	} else if rest, ok := strings.CutPrefix(mantissa, "+"); ok {
+		mantissa = rest
+	}
+
+	whole, frac, hasPoint := strings.Cut(mantissa, string(l.Decimal))
+	if whole+frac == "" || (hasPoint && (frac == "" || strings.ContainsRune(frac, l.Decimal))) {
+		return 0, parseError(s, strconv.ErrSyntax)
+	}
+	if !onlyDigits(frac) {
+		if strings.IndexFunc(frac, l.isGroup) >= 0 {
+			return 0, parseError(s, ErrInvalidGrouping)
+		}
+		return 0, parseError(s, strconv.ErrSyntax)
+	}
+	digits, err := l.ungroup(whole)
+	if err != nil {
+		return 0, parseError(s, err)
+	}
+
+	if percent {
+		exponent -= 2
+	}
+	canonical := sign + digits + "." + frac + "e" + strconv.Itoa(exponent)
+	f, err := strconv.ParseFloat(canonical, 64)
+	if err != nil {
+		return 0, parseError(s, strconv.ErrRange)
+	}
+	return f, nil
+}
+
+// Format writes f with precision digits after the decimal separator and the
+// integer digits grouped by three, e.g. "1 234,50" in LocaleFR. NaN and
+// infinities are written as "NaN", "+Inf" and "-Inf" in every locale, as are
+// they by the other Format methods.
+func (l Locale) Format(f float64, precision int) string {
+	if s, ok := nonFinite(f); ok {
+		return s
+	}
+	return l.localize(strconv.FormatFloat(f, 'f', max(precision, 0), 64))
+}
+
+// FormatPercent writes f as a percentage, so 0.125 becomes "12.5%" with one
+// digit of precision
+func (l Locale) FormatPercent(f float64, precision int) string {
+	if s, ok := nonFinite(f); ok {
+		return s
+	}
+	return l.Format(f*100, precision) + "%"
+}
+
+// FormatScientific writes f with one integer digit, precision digits after
+// the decimal separator and an exponent, e.g. "1,235e3" in LocaleDE
+func (l Locale) FormatScientific(f float64, precision int) string {
+	return l.formatExponent(f, precision, 1)
+}
+
+// FormatEngineering writes f like FormatScientific, but with an exponent that
+// is a multiple of three and one to three integer digits, e.g. "12.35e3"
+func (l Locale) FormatEngineering(f float64, precision int) string {
+	return l.formatExponent(f, precision, 3)
+}
+
+// ParseNumber reads a number whose locale is not known, working out the
+// separators from the input: "1,234.5" and "1.234,5" are both 1234.5, and
+// "1,5" is 1.5. A single "." or "," followed by exactly three digits, as in
+// "1,234", could be either and is rejected with ErrAmbiguousNumber.
+func ParseNumber(s string) (float64, error) {
+	mantissa, _, _, err := splitNumber(s)
+	if err != nil {
+		return 0, err
+	}
+	if rest, ok := cutSign(mantissa); ok {
+		mantissa = rest
+	}
+	mantissa = strings.TrimPrefix(mantissa, "+")
+
+	// Collect the separators in order of their last appearance
+	var seps []rune
+	count := map[rune]int{}
+	for _, r := range mantissa {
+		if isDigit(r) {
+			continue
+		}
+		if unicode.IsSpace(r) {
+			r = ' '
+		}
+		count[r]++
+		if i := indexRune(seps, r); i >= 0 {
+			seps = append(seps[:i], seps[i+1:]...)
+		}
+		seps = append(seps, r)
+	}
+
+	var l Locale
+	switch len(seps) {
+	case 0:
+		l = LocaleEN
+	case 1:
+		sep := seps[0]
+		switch {
+		case sep == ' ' || sep == '\'' || count[sep] > 1:
+			l = Locale{Decimal: otherPoint(sep), Group: sep}
+		case sep != '.' && sep != ',':
+			return 0, parseError(s, strconv.ErrSyntax)
+		default:
+			whole, frac, _ := strings.Cut(mantissa, string(sep))
+			// Groups never start with 0, and are not used with an exponent
+			if len(frac) == 3 && whole != "" && whole != "0" && !strings.ContainsAny(s, "eE") {
+				return 0, parseError(s, ErrAmbiguousNumber)
+			}
+			l = Locale{Decimal: sep, Group: otherPoint(sep)}
+		}
+	case 2:
+		// The separator that comes last is the decimal one
+		l = Locale{Decimal: seps[1], Group: seps[0]}
+		if count[l.Decimal] > 1 || !strings.ContainsRune(".,", l.Decimal) || !strings.ContainsRune(".,' ", l.Group) {
+			return 0, parseError(s, strconv.ErrSyntax)
+		}
+	default:
+		return 0, parseError(s, strconv.ErrSyntax)
+	}
+	return l.Parse(s)
+}
+
+// splitNumber trims s and separates its mantissa from an exponent and a
+// trailing percent sign
+func splitNumber(s string) (mantissa string, exponent int, percent bool, err error) {
+	mantissa = strings.TrimSpace(s)
+	if rest, ok := strings.CutSuffix(mantissa, "%"); ok {
+		mantissa, percent = strings.TrimRightFunc(rest, unicode.IsSpace), true
+	}
+	if i := strings.IndexAny(mantissa, "eE"); i >= 0 {
+		raw := mantissa[i+1:]
+		if r, ok := cutSign(raw); ok {
+			raw = "-" + r
+		}
+		exp, convErr := strconv.Atoi(raw)
+		if convErr != nil || !onlyDigits(strings.TrimLeft(raw, "+-")) {
+			return "", 0, false, parseError(s, strconv.ErrSyntax)
+		}
+		if exp > maxExponent || exp < -maxExponent {
+			return "", 0, false, parseError(s, strconv.ErrRange)
+		}
+		mantissa, exponent = mantissa[:i], exp
+	}
+	if mantissa == "" {
+		return "", 0, false, parseError(s, strconv.ErrSyntax)
+	}
+	return mantissa, exponent, percent, nil
+}
+
+// ungroup checks the group separators in the integer digits whole and
+// removes them. Separators must sit between groups of three digits, after a
+// first group of one to three.
+func (l Locale) ungroup(whole string) (string, error) {
+	if onlyDigits(whole) {
+		return whole, nil
+	}
+
+	var digits strings.Builder
+	group, first := 0, true
+	for _, r := range whole {
+		switch {
+		case isDigit(r):
+			digits.WriteRune(r)
+			group++
+		case l.isGroup(r):
+			if group == 0 || group > 3 || (!first && group != 3) {
+				return "", ErrInvalidGrouping
+			}
+			group, first = 0, false
+		default:
+			return "", strconv.ErrSyntax
+		}
+	}
+	if group != 3 {
+		return "", ErrInvalidGrouping
+	}
+	return digits.String(), nil
+}
+
+// isGroup reports whether r separates digit groups in l
+func (l Locale) isGroup(r rune) bool {
+	if isSpaceGroup(l.Group) {
+		return isSpaceGroup(r)
+	}
+	return r == l.Group && r != 0
+}
+
+// formatExponent writes f as a mantissa and an exponent that is a multiple of
+// step, falling back to strconv's form should f not convert to a Decimal
+func (l Locale) formatExponent(f float64, precision, step int) string {
+	if s, ok := nonFinite(f); ok {
+		return s
+	}
+	mantissa, exp, err := exponentForm(f, precision, step)
+	if err != nil {
+		var rawExp string
+		mantissa, rawExp, _ = strings.Cut(strconv.FormatFloat(f, 'e', max(precision, 0), 64), "e")
+		exp, _ = strconv.Atoi(rawExp)
+	}
+	return l.localize(mantissa) + "e" + strconv.Itoa(exp)
+}
+
+// nonFinite returns the unlocalized form of NaN and the infinities
+func nonFinite(f float64) (string, bool) {
+	switch {
+	case math.IsNaN(f):
+		return "NaN", true
+	case math.IsInf(f, 1):
+		return "+Inf", true
+	case math.IsInf(f, -1):
+		return "-Inf", true
+	}
+	return "", false
+}
+
+// localize rewrites a number formatted by strconv with l's separators
+func (l Locale) localize(number string) string {
+	sign := ""
+	if rest, ok := strings.CutPrefix(number, "-"); ok {
+		sign, number = "-", rest
+	}
+	whole, frac, hasPoint := strings.Cut(number, ".")
+
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 && l.Group != 0 {
+			b.WriteRune(l.Group)
+		}
+		b.WriteRune(r)
+	}
+	if hasPoint {
+		b.WriteRune(l.Decimal)
+		b.WriteString(frac)
+	}
+	return b.String()
+}
+
+// exponentForm writes f as a mantissa with precision digits after the point
+// times 10^exp, where exp is a multiple of step and the mantissa has at most
+// step integer digits. Rounding is half-even on the shortest decimal form of f,
+// so f must be finite.
+func exponentForm(f float64, precision, step int) (string, int, error) {
+	shortest := strconv.FormatFloat(f, 'e', -1, 64)
+	d, err := StringToDecimal(shortest)
+	if err != nil {
+		return "", 0, err
+	}
+	_, rawExp, _ := strings.Cut(shortest, "e")
+	exp, err := strconv.Atoi(rawExp)
+	if err != nil {
+		return "", 0, parseError(shortest, strconv.ErrSyntax)
+	}
+
+	limit := Decimal{coef: pow10(step)}
+	exp = floorDiv(exp, step) * step
+	for {
+		mantissa := d.shift(-exp).Round(precision, RoundHalfEven)
+		abs := mantissa
+		if abs.Sign() < 0 {
+			abs = Decimal{}.Subtract(abs)
+		}
+		if abs.Cmp(limit) < 0 {
+			return mantissa.String(), exp, nil
+		}
+		// Rounding carried into the next power of ten
+		exp += step
+	}
+}
+
+// floorDiv divides rounding toward negative infinity
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+// cutSign removes a leading minus sign, ASCII or U+2212
+func cutSign(s string) (string, bool) {
+	if rest, ok := strings.CutPrefix(s, "-"); ok {
+		return rest, true
+	}
+	return strings.CutPrefix(s, "\u2212")
+}
+
+// otherPoint returns the separator that pairs with sep: a comma for a point
+// and a point for anything else
+func otherPoint(sep rune) rune {
+	if sep == '.' {
+		return ','
+	}
+	return '.'
+}
+
+func isSpaceGroup(r rune) bool {
+	return r == ' ' || r == '\u00a0' || r == '\u202f'
+}
+
+func onlyDigits(s string) bool {
+	return strings.TrimLeft(s, "0123456789") == ""
+}
+
+func indexRune(runes []rune, r rune) int {
+	for i, x := range runes {
+		if x == r {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,163 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestLocaleParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		locale   Locale
+		input    string
+		expected float64
+		err      error
+	}{
+		{"plain", LocaleEN, "1234.5", 1234.5, nil},
+		{"grouped", LocaleEN, "1,234.50", 1234.5, nil},
+		{"millions", LocaleEN, "-12,345,678", -12345678, nil},
+		{"decimal comma", LocaleDE, "1.234,5", 1234.5, nil},
+		{"space groups", LocaleFR, "1 234,5", 1234.5, nil},
+		{"narrow no-break space", LocaleFR, "1\u202f234,5", 1234.5, nil},
+		{"no-break space", LocaleRU, "\u22121\u00a0000", -1000, nil},
+		{"apostrophe", LocaleCH, "1'000.25", 1000.25, nil},
+		{"scientific", LocaleDE, "1,5e-3", 0.0015, nil},
+		{"percent", LocaleEN, "12.5%", 0.125, nil},
+		{"percent with space", LocaleFR, "12,5 %", 0.125, nil},
+		{"leading separator", LocaleEN, ".5", 0.5, nil},
+		{"short group", LocaleEN, "1,23", 0, ErrInvalidGrouping},
+		{"long first group", LocaleEN, "1234,567", 0, ErrInvalidGrouping},
+		{"trailing separator", LocaleEN, "1,", 0, ErrInvalidGrouping},
+		{"trailing decimal separator", LocaleEN, "1.", 0, strconv.ErrSyntax},
+		{"empty fraction with exponent", LocaleDE, "1,e3", 0, strconv.ErrSyntax},
+		{"group in fraction", LocaleEN, "1.234,5", 0, ErrInvalidGrouping},
+		{"point in a comma locale", LocaleDE, "1.5", 0, ErrInvalidGrouping},
+		{"two decimal separators", LocaleEN, "1.2.3", 0, strconv.ErrSyntax},
+		{"letters", LocaleEN, "12abc", 0, strconv.ErrSyntax},
+		{"empty", LocaleEN, "", 0, strconv.ErrSyntax},
+		{"bad exponent", LocaleEN, "1e+", 0, strconv.ErrSyntax},
+		{"huge", LocaleEN, "1e400", 0, strconv.ErrRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.locale.Parse(tt.input)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("Expected %v, got %v (%v)", tt.err, err, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Parse(%q) = %v, want %v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+		err      error
+	}{
+		{"1234.5", 1234.5, nil},
+		{"1,234.50", 1234.5, nil},
+		{"1.234,50", 1234.5, nil},
+		{"1 234,5", 1234.5, nil},
+		{"1'234.5", 1234.5, nil},
+		{"1,5", 1.5, nil},
+		{"1.25", 1.25, nil},
+		{"0,125", 0.125, nil},
+		{"1,234,567", 1234567, nil},
+		{"1.234.567", 1234567, nil},
+		{"1 000", 1000, nil},
+		{"-2,5%", -0.025, nil},
+		{"1,234e2", 123.4, nil},
+		{"1,234", 0, ErrAmbiguousNumber},
+		{"-1.000", 0, ErrAmbiguousNumber},
+		{"1,234,5", 0, ErrInvalidGrouping},
+		{"1,", 0, strconv.ErrSyntax},
+		{"-2.%", 0, strconv.ErrSyntax},
+		{"1.234.567,8.9", 0, strconv.ErrSyntax},
+		{"1,2.3 4", 0, strconv.ErrSyntax},
+		{"abc", 0, strconv.ErrSyntax},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseNumber(tt.input)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("Expected %v, got %v (%v)", tt.err, err, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseNumber(%q) = %v, want %v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLocaleFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		got      string
+		expected string
+	}{
+		{"grouped", LocaleEN.Format(1234567.891, 2), "1,234,567.89"},
+		{"decimal comma", LocaleDE.Format(-1234.5, 2), "-1.234,50"},
+		{"narrow no-break space", LocaleFR.Format(1234.5, 1), "1\u202f234,5"},
+		{"small", LocaleEN.Format(999, 0), "999"},
+		{"percent", LocaleEN.FormatPercent(0.125, 1), "12.5%"},
+		{"scientific", LocaleDE.FormatScientific(1234.5, 3), "1,234e3"},
+		{"scientific small", LocaleEN.FormatScientific(-0.00015, 1), "-1.5e-4"},
+		{"scientific carry", LocaleEN.FormatScientific(9.96, 1), "1.0e1"},
+		{"engineering", LocaleEN.FormatEngineering(12346, 2), "12.35e3"},
+		{"engineering small", LocaleEN.FormatEngineering(0.000123, 1), "123.0e-6"},
+		{"engineering carry", LocaleEN.FormatEngineering(999.96, 1), "1.0e3"},
+		{"engineering tie", LocaleEN.FormatEngineering(99.5, 0), "100e0"},
+		{"engineering zero", LocaleEN.FormatEngineering(0, 2), "0.00e0"},
+		{"infinity", LocaleEN.Format(math.Inf(1), 2), "+Inf"},
+		{"negative infinity", LocaleDE.Format(math.Inf(-1), 2), "-Inf"},
+		{"not a number", LocaleFR.Format(math.NaN(), 2), "NaN"},
+		{"percent infinity", LocaleEN.FormatPercent(math.Inf(-1), 1), "-Inf"},
+		{"scientific infinity", LocaleEN.FormatScientific(math.Inf(1), 2), "+Inf"},
+		{"scientific not a number", LocaleDE.FormatScientific(math.NaN(), 2), "NaN"},
+		{"engineering not a number", LocaleEN.FormatEngineering(math.NaN(), 2), "NaN"},
+		{"engineering negative infinity", LocaleEN.FormatEngineering(math.Inf(-1), 2), "-Inf"},
+		{"scientific largest", LocaleEN.FormatScientific(math.MaxFloat64, 2), "1.80e308"},
+		{"engineering smallest", LocaleEN.FormatEngineering(math.SmallestNonzeroFloat64, 1), "5.0e-324"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, tt.got)
+			}
+		})
+	}
+
+	// Everything formatted parses back in the same locale
+	for _, locale := range []Locale{LocaleEN, LocaleDE, LocaleFR, LocaleCH} {
+		for _, s := range []string{
+			locale.Format(-9876543.21, 2),
+			locale.FormatPercent(0.0375, 2),
+			locale.FormatScientific(6.02214076e23, 8),
+			locale.FormatEngineering(-0.0047, 1),
+		} {
+			if _, err := locale.Parse(s); err != nil {
+				t.Errorf("Expected %q to parse back, got %v", s, err)
+			}
+		}
+	}
+}
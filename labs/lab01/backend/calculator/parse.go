@@ -0,0 +1,353 @@
+package calculator
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+)
+
+// tokenKind classifies a token of an expression
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenOp
+	tokenLParen
+	tokenRParen
+	tokenComma
+	tokenAssign
+)
+
+// token is one lexeme of an expression; col is its 1-based column
+type token struct {
+	kind tokenKind
+	text string
+	col  int
+}
+
+// describe renders a token for error messages
+func (t token) describe() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of expression"
+	case tokenNumber:
+		return fmt.Sprintf("number %s", t.text)
+	}
+	return fmt.Sprintf("'%s'", t.text)
+}
+
+// tokenize splits an expression into tokens, ending with a tokenEOF
+func tokenize(expr string) ([]token, error) {
+	runes := []rune(expr)
+	var tokens []token
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case isDigit(r) || r == '.':
+			i = scanNumber(runes, i)
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), col: start + 1})
+			continue
+		case unicode.IsLetter(r) || r == '_':
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || isDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:i]), col: start + 1})
+			continue
+		}
+
+		tok := token{text: string(r), col: start + 1}
+		switch r {
+		case '+', '-', '*', '/', '%', '^':
+			tok.kind = tokenOp
+		case '(':
+			tok.kind = tokenLParen
+		case ')':
+			tok.kind = tokenRParen
+		case ',':
+			tok.kind = tokenComma
+		case '=':
+			tok.kind = tokenAssign
+		default:
+			return nil, &SyntaxError{Col: tok.col, Msg: fmt.Sprintf("unexpected '%c'", r)}
+		}
+		tokens = append(tokens, tok)
+		i++
+	}
+	return append(tokens, token{kind: tokenEOF, col: len(runes) + 1}), nil
+}
+
+// scanNumber returns the end of the number starting at runes[i]: digits, an
+// optional fraction and an optional exponent
+func scanNumber(runes []rune, i int) int {
+	for i < len(runes) && isDigit(runes[i]) {
+		i++
+	}
+	if i < len(runes) && runes[i] == '.' {
+		i++
+		for i < len(runes) && isDigit(runes[i]) {
+			i++
+		}
+	}
+	if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+		j := i + 1
+		if j < len(runes) && (runes[j] == '+' || runes[j] == '-') {
+			j++
+		}
+		// Only an exponent with digits belongs to the number
+		if j < len(runes) && isDigit(runes[j]) {
+			for i = j; i < len(runes) && isDigit(runes[i]); i++ {
+			}
+		}
+	}
+	return i
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// node is a parsed expression
+type node interface {
+	column() int
+}
+
+type numberNode struct {
+	col   int
+	value float64
+}
+
+type identNode struct {
+	col  int
+	name string
+}
+
+type unaryNode struct {
+	col int
+	op  string
+	x   node
+}
+
+type binaryNode struct {
+	col  int
+	op   string
+	x, y node
+}
+
+type callNode struct {
+	col  int
+	name string
+	args []node
+}
+
+func (n *numberNode) column() int { return n.col }
+func (n *identNode) column() int  { return n.col }
+func (n *unaryNode) column() int  { return n.col }
+func (n *binaryNode) column() int { return n.col }
+func (n *callNode) column() int   { return n.col }
+
+// parser is a recursive descent parser over the grammar
+//
+//	expr   = term { ("+" | "-") term }
+//	term   = unary { ("*" | "/" | "%") unary }
+//	unary  = ("+" | "-") unary | power
+//	power  = atom [ "^" unary ]
+//	atom   = number | ident [ "(" [ expr { "," expr } ] ")" ] | "(" expr ")"
+//
+// so "^" binds tighter than unary minus and associates to the right.
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// parse parses a complete expression
+func parse(expr string) (node, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	return (&parser{tokens: tokens}).complete()
+}
+
+// parseStatement parses an expression or an assignment "name = expr". For an
+// assignment it returns the name and its column.
+func parseStatement(input string) (string, int, node, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	p := &parser{tokens: tokens}
+	if len(tokens) > 2 && tokens[0].kind == tokenIdent && tokens[1].kind == tokenAssign {
+		p.pos = 2
+		n, err := p.complete()
+		return tokens[0].text, tokens[0].col, n, err
+	}
+	n, err := p.complete()
+	return "", 0, n, err
+}
+
+// complete parses an expression that must run to the end of the input
+func (p *parser) complete() (node, error) {
+	n, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.unexpected(tok)
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// acceptOp consumes the next token if it is one of ops
+func (p *parser) acceptOp(ops ...string) (token, bool) {
+	tok := p.peek()
+	if tok.kind != tokenOp {
+		return tok, false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			return p.next(), true
+		}
+	}
+	return tok, false
+}
+
+func (p *parser) unexpected(tok token) error {
+	return &SyntaxError{Col: tok.col, Msg: "unexpected " + tok.describe()}
+}
+
+func (p *parser) expr() (node, error) {
+	x, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.acceptOp("+", "-")
+		if !ok {
+			return x, nil
+		}
+		y, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		x = &binaryNode{col: op.col, op: op.text, x: x, y: y}
+	}
+}
+
+func (p *parser) term() (node, error) {
+	x, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.acceptOp("*", "/", "%")
+		if !ok {
+			return x, nil
+		}
+		y, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		x = &binaryNode{col: op.col, op: op.text, x: x, y: y}
+	}
+}
+
+func (p *parser) unary() (node, error) {
+	if op, ok := p.acceptOp("+", "-"); ok {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{col: op.col, op: op.text, x: x}, nil
+	}
+	return p.power()
+}
+
+func (p *parser) power() (node, error) {
+	x, err := p.atom()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.acceptOp("^")
+	if !ok {
+		return x, nil
+	}
+	y, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return &binaryNode{col: op.col, op: op.text, x: x, y: y}, nil
+}
+
+func (p *parser) atom() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNumber:
+		value, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, &SyntaxError{Col: tok.col, Msg: fmt.Sprintf("invalid number %s", tok.text)}
+		}
+		return &numberNode{col: tok.col, value: value}, nil
+	case tokenIdent:
+		if p.peek().kind != tokenLParen {
+			return &identNode{col: tok.col, name: tok.text}, nil
+		}
+		p.next()
+		args, err := p.args()
+		if err != nil {
+			return nil, err
+		}
+		return &callNode{col: tok.col, name: tok.text, args: args}, nil
+	case tokenLParen:
+		x, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, p.unexpected(closing)
+		}
+		return x, nil
+	}
+	return nil, p.unexpected(tok)
+}
+
+// args parses a call's arguments after the opening parenthesis
+func (p *parser) args() ([]node, error) {
+	var args []node
+	if p.peek().kind == tokenRParen {
+		p.next()
+		return args, nil
+	}
+	for {
+		arg, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+
+		switch tok := p.next(); tok.kind {
+		case tokenComma:
+		case tokenRParen:
+			return args, nil
+		default:
+			return nil, p.unexpected(tok)
+		}
+	}
+}
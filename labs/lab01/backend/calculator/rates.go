@@ -0,0 +1,94 @@
+package calculator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// ErrInvalidRates is returned for a malformed currency rate table
+var ErrInvalidRates = errors.New("invalid rate table")
+
+// Rates is a currency rate table: one unit of the Base currency buys
+// Rates[code] of each other currency. Codes are ISO 4217, such as "USD".
+type Rates struct {
+	Base  string             `json:"base"`
+	Date  string             `json:"date,omitempty"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// LoadRates reads a rate table from a local JSON file such as
+//
+//	{"base": "EUR", "date": "2025-06-02", "rates": {"USD": 1.08, "GBP": 0.85}}
+func LoadRates(path string) (Rates, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Rates{}, err
+	}
+	defer f.Close()
+	return ParseRates(f)
+}
+
+// ParseRates reads a rate table in the JSON form LoadRates accepts
+func ParseRates(r io.Reader) (Rates, error) {
+	var rates Rates
+	if err := json.NewDecoder(r).Decode(&rates); err != nil {
+		return Rates{}, fmt.Errorf("%w: %v", ErrInvalidRates, err)
+	}
+	if err := rates.validate(); err != nil {
+		return Rates{}, err
+	}
+	return rates, nil
+}
+
+// AddRates registers the currencies of a rate table as units, replacing the
+// currencies of any table added before. Amounts of different currencies can
+// then be converted and added like lengths.
+func (u *Units) AddRates(rates Rates) error {
+	if err := rates.validate(); err != nil {
+		return err
+	}
+
+	for symbol, unit := range u.units {
+		if unit.Dim == (Dimension{DimCurrency: 1}) {
+			delete(u.units, symbol)
+		}
+	}
+	u.units[rates.Base] = Unit{Symbol: rates.Base, Dim: Dimension{DimCurrency: 1}, Factor: 1}
+	for code, rate := range rates.Rates {
+		u.units[code] = Unit{Symbol: code, Dim: Dimension{DimCurrency: 1}, Factor: 1 / rate}
+	}
+	return nil
+}
+
+// validate checks the currency codes and that every rate is positive
+func (r Rates) validate() error {
+	if !isCurrencyCode(r.Base) {
+		return fmt.Errorf("%w: base currency %q is not a currency code", ErrInvalidRates, r.Base)
+	}
+	for code, rate := range r.Rates {
+		if !isCurrencyCode(code) {
+			return fmt.Errorf("%w: %q is not a currency code", ErrInvalidRates, code)
+		}
+		if rate <= 0 || math.IsNaN(rate) || math.IsInf(rate, 0) || (code == r.Base && rate != 1) {
+			return fmt.Errorf("%w: rate %v for %s", ErrInvalidRates, rate, code)
+		}
+	}
+	return nil
+}
+
+// isCurrencyCode reports whether s has the form of an ISO 4217 code
+func isCurrencyCode(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
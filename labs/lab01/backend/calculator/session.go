@@ -0,0 +1,156 @@
+package calculator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+)
+
+// Session limits
+const (
+	// MaxHistory is the number of entries a Session keeps; older ones are dropped
+	MaxHistory = 1000
+	// MaxVariables is the number of variables a Session can hold
+	MaxVariables = 1000
+)
+
+// ErrTooManyVariables is returned when assigning a new variable to, or loading,
+// a session with more than MaxVariables
+var ErrTooManyVariables = errors.New("too many variables")
+
+// ansName is the register holding the last result
+const ansName = "ans"
+
+// Entry is one successfully evaluated input of a Session
+type Entry struct {
+	Input  string  `json:"input"`
+	Result float64 `json:"result"`
+}
+
+// Session evaluates inputs with named variables, an ans register holding the
+// last result, and a history of what was evaluated. It can be saved and
+// loaded with encoding/json. A Session is not safe for concurrent use.
+type Session struct {
+	// vars holds the variables and the ans register
+	vars    map[string]float64
+	history []Entry
+}
+
+// sessionJSON is the saved form of a Session
+type sessionJSON struct {
+	Ans       float64            `json:"ans"`
+	Variables map[string]float64 `json:"variables"`
+	History   []Entry            `json:"history"`
+}
+
+// NewSession creates an empty session with ans set to 0
+func NewSession() *Session {
+	return &Session{vars: map[string]float64{ansName: 0}}
+}
+
+// Eval evaluates an expression as Eval does, or an assignment such as
+// "x = 3 * ans" that also stores the result under the name. Names resolve to
+// variables, then to ans, pi and e. Assigning to ans, a constant or a function
+// name returns an *EvalError wrapping ErrReservedName, and a new variable
+// beyond MaxVariables one wrapping ErrTooManyVariables. On success the result
+// is stored in ans and the input added to the history.
+func (s *Session) Eval(input string) (float64, error) {
+	name, col, n, err := parseStatement(input)
+	if err != nil {
+		return 0, err
+	}
+	if name != "" && reserved(name) {
+		return 0, &EvalError{Col: col, Err: fmt.Errorf("%w '%s'", ErrReservedName, name)}
+	}
+	// vars also holds ans
+	if _, exists := s.vars[name]; name != "" && !exists && len(s.vars) > MaxVariables {
+		return 0, &EvalError{Col: col, Err: fmt.Errorf("%w: at most %d", ErrTooManyVariables, MaxVariables)}
+	}
+
+	result, err := evaluator{vars: s.vars}.eval(n)
+	if err != nil {
+		return 0, err
+	}
+	s.vars[ansName] = result
+	if name != "" {
+		s.vars[name] = result
+	}
+	s.history = append(s.history, Entry{Input: input, Result: result})
+	if len(s.history) > MaxHistory {
+		s.history = slices.Delete(s.history, 0, len(s.history)-MaxHistory)
+	}
+	return result, nil
+}
+
+// Ans returns the last result
+func (s *Session) Ans() float64 {
+	return s.vars[ansName]
+}
+
+// Vars returns a copy of the session's variables, without ans
+func (s *Session) Vars() map[string]float64 {
+	vars := maps.Clone(s.vars)
+	delete(vars, ansName)
+	return vars
+}
+
+// History returns the evaluated inputs, oldest first
+func (s *Session) History() []Entry {
+	return slices.Clone(s.history)
+}
+
+// MarshalJSON saves the session's ans, variables and history
+func (s *Session) MarshalJSON() ([]byte, error) {
+	history := s.history
+	if history == nil {
+		history = []Entry{}
+	}
+	return json.Marshal(sessionJSON{Ans: s.Ans(), Variables: s.Vars(), History: history})
+}
+
+// UnmarshalJSON loads a saved session, rejecting variable names that could
+// not have been assigned and more than MaxVariables variables
+func (s *Session) UnmarshalJSON(data []byte) error {
+	var saved sessionJSON
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+	if len(saved.Variables) > MaxVariables {
+		return fmt.Errorf("%w: at most %d", ErrTooManyVariables, MaxVariables)
+	}
+
+	vars := map[string]float64{ansName: saved.Ans}
+	for name, value := range saved.Variables {
+		if !isName(name) || reserved(name) {
+			return fmt.Errorf("invalid variable name %q", name)
+		}
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return fmt.Errorf("invalid value for variable %q", name)
+		}
+		vars[name] = value
+	}
+	history := saved.History
+	if len(history) > MaxHistory {
+		history = history[len(history)-MaxHistory:]
+	}
+
+	s.vars = vars
+	s.history = slices.Clone(history)
+	return nil
+}
+
+// reserved reports whether name cannot be assigned
+func reserved(name string) bool {
+	_, constant := constants[name]
+	_, function := functions[name]
+	return name == ansName || constant || function
+}
+
+// isName reports whether s is a single identifier
+func isName(s string) bool {
+	tokens, err := tokenize(s)
+	return err == nil && len(tokens) == 2 && tokens[0].kind == tokenIdent && tokens[0].text == s
+}
@@ -0,0 +1,143 @@
+package calculator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestSession(t *testing.T) {
+	s := NewSession()
+	steps := []struct {
+		input    string
+		expected float64
+	}{
+		{"x = 3", 3},
+		{"y = x * 2", 6},
+		{"x + y", 9},
+		{"ans / 3", 3},
+		{"x = ans ^ 2", 9},
+		{"sqrt(x) + pi - pi", 3},
+	}
+	for _, step := range steps {
+		got, err := s.Eval(step.input)
+		if err != nil {
+			t.Fatalf("Eval(%q) failed: %v", step.input, err)
+		}
+		if got != step.expected {
+			t.Errorf("Eval(%q) = %v, want %v", step.input, got, step.expected)
+		}
+	}
+
+	if s.Ans() != 3 {
+		t.Errorf("Expected ans 3, got %v", s.Ans())
+	}
+	if vars := s.Vars(); !maps.Equal(vars, map[string]float64{"x": 9, "y": 6}) {
+		t.Errorf("Unexpected variables: %v", vars)
+	}
+	history := s.History()
+	if len(history) != len(steps) || history[1] != (Entry{Input: "y = x * 2", Result: 6}) {
+		t.Errorf("Unexpected history: %+v", history)
+	}
+}
+
+func TestSessionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected error
+		col      int
+	}{
+		{"ans = 1", ErrReservedName, 1},
+		{"pi = 3", ErrReservedName, 1},
+		{"sqrt = 2", ErrReservedName, 1},
+		{"z = w + 1", ErrUnknownName, 5},
+		{"x = 1 / 0", ErrDivisionByZero, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			s := NewSession()
+			_, err := s.Eval(tt.input)
+			var evalErr *EvalError
+			if !errors.Is(err, tt.expected) || !errors.As(err, &evalErr) || evalErr.Col != tt.col {
+				t.Errorf("Expected %v at col %d, got %v", tt.expected, tt.col, err)
+			}
+			if len(s.Vars()) != 0 || len(s.History()) != 0 {
+				t.Errorf("Expected a failed input to change nothing, got %v %v", s.Vars(), s.History())
+			}
+		})
+	}
+
+	if _, err := NewSession().Eval("x = "); err == nil || err.Error() != "unexpected end of expression at col 5" {
+		t.Errorf("Expected a syntax error, got %v", err)
+	}
+}
+
+func TestSessionSaveLoad(t *testing.T) {
+	s := NewSession()
+	s.Eval("rate = 0.2")
+	s.Eval("100 * rate")
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("Failed to save session: %v", err)
+	}
+	loaded := NewSession()
+	if err := json.Unmarshal(data, loaded); err != nil {
+		t.Fatalf("Failed to load session: %v", err)
+	}
+	if loaded.Ans() != 20 || !maps.Equal(loaded.Vars(), s.Vars()) || !slices.Equal(loaded.History(), s.History()) {
+		t.Errorf("Expected %s to load back, got %v %v %v", data, loaded.Ans(), loaded.Vars(), loaded.History())
+	}
+	if got, err := loaded.Eval("ans + rate"); err != nil || got != 20.2 {
+		t.Errorf("Expected the loaded session to be usable, got %v %v", got, err)
+	}
+
+	for _, invalid := range []string{
+		`{"variables": {"pi": 3}}`,
+		`{"variables": {"two words": 1}}`,
+		`{"variables": {"x": "1"}}`,
+	} {
+		if err := json.Unmarshal([]byte(invalid), NewSession()); err == nil {
+			t.Errorf("Expected %s to be rejected", invalid)
+		}
+	}
+}
+
+func TestSessionMaxVariables(t *testing.T) {
+	s := NewSession()
+	for i := range MaxVariables {
+		if _, err := s.Eval(fmt.Sprintf("v%d = %d", i, i)); err != nil {
+			t.Fatalf("Failed to assign variable %d: %v", i, err)
+		}
+	}
+
+	_, err := s.Eval("extra = 1")
+	var evalErr *EvalError
+	if !errors.Is(err, ErrTooManyVariables) || !errors.As(err, &evalErr) || evalErr.Col != 1 {
+		t.Errorf("Expected %v at col 1, got %v", ErrTooManyVariables, err)
+	}
+	if got, err := s.Eval("v0 = 5"); err != nil || got != 5 {
+		t.Errorf("Expected reassigning a variable to succeed, got %v %v", got, err)
+	}
+	if len(s.Vars()) != MaxVariables {
+		t.Errorf("Expected %d variables, got %d", MaxVariables, len(s.Vars()))
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("Failed to save session: %v", err)
+	}
+	if err := json.Unmarshal(data, NewSession()); err != nil {
+		t.Errorf("Expected a full session to load, got %v", err)
+	}
+	vars := s.Vars()
+	vars["extra"] = 1
+	data, _ = json.Marshal(map[string]any{"variables": vars})
+	if err := json.Unmarshal(data, NewSession()); !errors.Is(err, ErrTooManyVariables) {
+		t.Errorf("Expected %v, got %v", ErrTooManyVariables, err)
+	}
+}
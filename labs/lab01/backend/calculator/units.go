@@ -0,0 +1,317 @@
+package calculator
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Unit errors
+var (
+	ErrUnknownUnit       = errors.New("unknown unit")
+	ErrIncompatibleUnits = errors.New("incompatible units")
+)
+
+// Base dimensions, indexing a Dimension
+const (
+	DimLength = iota
+	DimMass
+	DimTime
+	DimTemperature
+	DimData
+	DimCurrency
+	numDimensions
+)
+
+// Dimension is the power of each base dimension in a unit: speed has length
+// 1 and time -1. The zero Dimension is dimensionless.
+type Dimension [numDimensions]int
+
+// Unit is a unit of measurement. A value v in the unit is v*Factor + Offset
+// in the base unit of its dimension; only temperatures have an offset. The
+// zero Unit is that of plain numbers.
+type Unit struct {
+	Symbol string
+	Dim    Dimension
+	Factor float64
+	Offset float64
+}
+
+// Quantity is a value with a unit
+type Quantity struct {
+	Value float64
+	Unit  Unit
+}
+
+// Units is a set of units that quantities can be parsed and converted with.
+// Modify it with AddRates before sharing it between goroutines.
+type Units struct {
+	units map[string]Unit
+}
+
+// NewUnits returns the built-in units of length, mass, time, temperature and
+// data size. Currencies are added from a rate table with AddRates.
+func NewUnits() *Units {
+	u := &Units{units: make(map[string]Unit)}
+	add := func(dim int, factor float64, symbols ...string) {
+		var d Dimension
+		d[dim] = 1
+		for _, symbol := range symbols {
+			u.units[symbol] = Unit{Symbol: symbols[0], Dim: d, Factor: factor}
+		}
+	}
+
+	add(DimLength, 1, "m")
+	add(DimLength, 1e3, "km")
+	add(DimLength, 1e-2, "cm")
+	add(DimLength, 1e-3, "mm")
+	add(DimLength, 1e-6, "µm", "um")
+	add(DimLength, 1e-9, "nm")
+	add(DimLength, 0.0254, "in")
+	add(DimLength, 0.3048, "ft")
+	add(DimLength, 0.9144, "yd")
+	add(DimLength, 1609.344, "mi")
+	add(DimLength, 1852, "nmi")
+
+	add(DimMass, 1, "kg")
+	add(DimMass, 1e-3, "g")
+	add(DimMass, 1e-6, "mg")
+	add(DimMass, 1e3, "t")
+	add(DimMass, 0.45359237, "lb")
+	add(DimMass, 0.45359237/16, "oz")
+
+	add(DimTime, 1, "s")
+	add(DimTime, 1e-3, "ms")
+	add(DimTime, 1e-6, "µs", "us")
+	add(DimTime, 1e-9, "ns")
+	add(DimTime, 60, "min")
+	add(DimTime, 3600, "h")
+	add(DimTime, 86400, "d")
+	add(DimTime, 7*86400, "wk")
+
+	temperature := func(factor, offset float64, symbols ...string) {
+		for _, symbol := range symbols {
+			u.units[symbol] = Unit{Symbol: symbols[0], Dim: Dimension{DimTemperature: 1}, Factor: factor, Offset: offset}
+		}
+	}
+	temperature(1, 0, "K")
+	temperature(1, 273.15, "°C", "degC")
+	temperature(5.0/9, 273.15-32*5.0/9, "°F", "degF")
+
+	add(DimData, 1, "B")
+	add(DimData, 0.125, "bit")
+	for i, prefix := range []string{"k", "M", "G", "T"} {
+		decimal := pow(1000, i+1)
+		add(DimData, decimal, prefix+"B")
+		add(DimData, decimal/8, prefix+"bit")
+		add(DimData, pow(1024, i+1), strings.ToUpper(prefix)+"iB")
+	}
+	return u
+}
+
+func pow(base float64, n int) float64 {
+	result := 1.0
+	for range n {
+		result *= base
+	}
+	return result
+}
+
+// Lookup returns the unit with the given symbol
+func (u *Units) Lookup(symbol string) (Unit, error) {
+	unit, ok := u.units[symbol]
+	if !ok {
+		return Unit{}, fmt.Errorf("%w %q", ErrUnknownUnit, symbol)
+	}
+	return unit, nil
+}
+
+// Parse reads a quantity such as "3 m", "2.5kg" or "100 USD". Without a space
+// the number is the longest prefix that reads as one, so "1e3m" is 1000 m.
+// The number must be a finite decimal; "NaN kg" and "inf m" are rejected.
+func (u *Units) Parse(s string) (Quantity, error) {
+	s = strings.TrimSpace(s)
+	number, symbol, found := strings.Cut(s, " ")
+	if !found {
+		if number, symbol = splitQuantity(s); symbol == "" {
+			return Quantity{}, parseError(s, strconv.ErrSyntax)
+		}
+	}
+
+	// Only plain decimals: strconv also reads NaN, Inf and hex floats
+	if strings.TrimLeft(number, "0123456789.+-eE") != "" {
+		return Quantity{}, parseError(s, strconv.ErrSyntax)
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return Quantity{}, parseError(s, strconv.ErrRange)
+		}
+		return Quantity{}, parseError(s, strconv.ErrSyntax)
+	}
+	unit, err := u.Lookup(strings.TrimSpace(symbol))
+	if err != nil {
+		return Quantity{}, err
+	}
+	return Quantity{Value: value, Unit: unit}, nil
+}
+
+// splitQuantity splits a quantity written without a space into the longest
+// prefix that parses as a number, even if out of range, and the unit symbol
+func splitQuantity(s string) (number, symbol string) {
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return !isDigit(r) && !strings.ContainsRune(".+-eE", r)
+	})
+	if end < 0 {
+		end = len(s)
+	}
+	for i := end; i > 0; i-- {
+		if _, err := strconv.ParseFloat(s[:i], 64); err == nil || errors.Is(err, strconv.ErrRange) {
+			return s[:i], s[i:]
+		}
+	}
+	return s[:end], s[end:]
+}
+
+// Convert expresses q in the unit with the given symbol
+func (u *Units) Convert(q Quantity, symbol string) (Quantity, error) {
+	unit, err := u.Lookup(symbol)
+	if err != nil {
+		return Quantity{}, err
+	}
+	return q.Convert(unit)
+}
+
+// Convert expresses q in unit, which must have the same dimension
+func (q Quantity) Convert(unit Unit) (Quantity, error) {
+	if q.Unit.Dim != unit.Dim {
+		return Quantity{}, fmt.Errorf("%w: cannot convert %s to %s", ErrIncompatibleUnits, q.Unit.name(), unit.name())
+	}
+	base := q.Value*q.Unit.factor() + q.Unit.Offset
+	return Quantity{Value: (base - unit.Offset) / unit.factor(), Unit: unit}, nil
+}
+
+// Add returns q + r in q's unit. Returns ErrIncompatibleUnits if the
+// dimensions differ, as for metres and seconds, or if temperatures with
+// different zero points are added, since it is unclear whether r is a
+// temperature or a difference.
+func (q Quantity) Add(r Quantity) (Quantity, error) {
+	r, err := q.operand(r, "add")
+	if err != nil {
+		return Quantity{}, err
+	}
+	return Quantity{Value: Add(q.Value, r.Value), Unit: q.Unit}, nil
+}
+
+// Subtract returns q - r in q's unit, with the same rules as Add
+func (q Quantity) Subtract(r Quantity) (Quantity, error) {
+	r, err := q.operand(r, "subtract")
+	if err != nil {
+		return Quantity{}, err
+	}
+	return Quantity{Value: Subtract(q.Value, r.Value), Unit: q.Unit}, nil
+}
+
+// Multiply returns q × r in a derived unit such as "m*s", whose dimension is
+// the sum of theirs. Temperatures with an offset cannot be multiplied.
+func (q Quantity) Multiply(r Quantity) (Quantity, error) {
+	if err := checkScalable(q, r, "multiply"); err != nil {
+		return Quantity{}, err
+	}
+	unit := Unit{Factor: q.Unit.factor() * r.Unit.factor()}
+	for i := range unit.Dim {
+		unit.Dim[i] = q.Unit.Dim[i] + r.Unit.Dim[i]
+	}
+	unit.Symbol = joinSymbols(q.Unit.Symbol, "*", r.Unit.Symbol)
+	return Quantity{Value: Multiply(q.Value, r.Value), Unit: unit}, nil
+}
+
+// Divide returns q / r in a derived unit such as "m/s". Quantities of the
+// same dimension divide to a plain number, so 3 m / 50 cm is 6. Returns
+// ErrDivisionByZero if r is zero.
+func (q Quantity) Divide(r Quantity) (Quantity, error) {
+	if err := checkScalable(q, r, "divide"); err != nil {
+		return Quantity{}, err
+	}
+	if q.Unit.Dim == r.Unit.Dim {
+		r, _ = r.Convert(q.Unit)
+		value, err := Divide(q.Value, r.Value)
+		return Quantity{Value: value}, err
+	}
+
+	value, err := Divide(q.Value, r.Value)
+	if err != nil {
+		return Quantity{}, err
+	}
+	unit := Unit{Factor: q.Unit.factor() / r.Unit.factor()}
+	for i := range unit.Dim {
+		unit.Dim[i] = q.Unit.Dim[i] - r.Unit.Dim[i]
+	}
+	unit.Symbol = joinSymbols(q.Unit.Symbol, "/", r.Unit.Symbol)
+	return Quantity{Value: value, Unit: unit}, nil
+}
+
+// String formats q as its value followed by its unit, e.g. "3.5 km"
+func (q Quantity) String() string {
+	value := strconv.FormatFloat(q.Value, 'g', -1, 64)
+	if q.Unit.Symbol == "" {
+		return value
+	}
+	return value + " " + q.Unit.Symbol
+}
+
+// operand converts r to q's unit for addition or subtraction
+func (q Quantity) operand(r Quantity, verb string) (Quantity, error) {
+	if q.Unit.Dim != r.Unit.Dim || ((q.Unit.Offset != 0 || r.Unit.Offset != 0) && q.Unit.Symbol != r.Unit.Symbol) {
+		return Quantity{}, fmt.Errorf("%w: cannot %s %s and %s", ErrIncompatibleUnits, verb, q.Unit.name(), r.Unit.name())
+	}
+	return r.Convert(q.Unit)
+}
+
+// checkScalable rejects units with an offset, whose products mean nothing
+func checkScalable(q, r Quantity, verb string) error {
+	for _, unit := range []Unit{q.Unit, r.Unit} {
+		if unit.Offset != 0 {
+			return fmt.Errorf("%w: cannot %s %s, convert to K first", ErrIncompatibleUnits, verb, unit.Symbol)
+		}
+	}
+	return nil
+}
+
+// factor returns the unit's Factor, which is 1 for the zero Unit
+func (u Unit) factor() float64 {
+	if u.Factor == 0 {
+		return 1
+	}
+	return u.Factor
+}
+
+// name returns the unit's symbol, or "number" for a dimensionless unit
+func (u Unit) name() string {
+	if u.Symbol == "" {
+		return "number"
+	}
+	return u.Symbol
+}
+
+// joinSymbols builds a derived unit symbol, bracketing operands that are
+// themselves derived
+func joinSymbols(a, op, b string) string {
+	bracket := func(s string) string {
+		if strings.ContainsAny(s, "*/") {
+			return "(" + s + ")"
+		}
+		return s
+	}
+	switch {
+	case a == "":
+		if op == "*" {
+			return b
+		}
+		return "1/" + bracket(b)
+	case b == "":
+		return a
+	}
+	return bracket(a) + op + bracket(b)
+}
@@ -0,0 +1,199 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	units := NewUnits()
+
+	tests := []struct {
+		from     string
+		to       string
+		expected float64
+	}{
+		{"1.5 km", "m", 1500},
+		{"1 mi", "ft", 5280},
+		{"12in", "cm", 30.48},
+		{"1e3m", "km", 1},
+		{"2.5E-3km", "m", 2.5},
+		{"-1.5e+1°C", "K", 258.15},
+		{"2 lb", "kg", 0.90718474},
+		{"16 oz", "lb", 1},
+		{"90 min", "h", 1.5},
+		{"1 wk", "d", 7},
+		{"100 °C", "°F", 212},
+		{"-40 degF", "degC", -40},
+		{"0 K", "°C", -273.15},
+		{"1 GiB", "MiB", 1024},
+		{"1 GB", "MB", 1000},
+		{"100 Mbit", "MB", 12.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			q, err := units.Parse(tt.from)
+			if err != nil {
+				t.Fatalf("Failed to parse %q: %v", tt.from, err)
+			}
+			got, err := units.Convert(q, tt.to)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if math.Abs(got.Value-tt.expected) > 1e-9 {
+				t.Errorf("Expected %v %s, got %v", tt.expected, tt.to, got)
+			}
+		})
+	}
+
+	q, _ := units.Parse("3 m")
+	if _, err := units.Convert(q, "s"); !errors.Is(err, ErrIncompatibleUnits) {
+		t.Errorf("Expected ErrIncompatibleUnits, got %v", err)
+	}
+	if _, err := units.Convert(q, "parsec"); !errors.Is(err, ErrUnknownUnit) {
+		t.Errorf("Expected ErrUnknownUnit, got %v", err)
+	}
+	for _, malformed := range []string{"three m", "12", "m", "1e400m", "NaN kg", "inf m", "-Infinity s", "0x1p3 m", "1_000 m"} {
+		if _, err := units.Parse(malformed); err == nil {
+			t.Errorf("Expected an error for %q", malformed)
+		}
+	}
+}
+
+func TestQuantityArithmetic(t *testing.T) {
+	units := NewUnits()
+	parse := func(s string) Quantity {
+		q, err := units.Parse(s)
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %v", s, err)
+		}
+		return q
+	}
+
+	tests := []struct {
+		name     string
+		op       func(Quantity, Quantity) (Quantity, error)
+		a, b     Quantity
+		expected string
+	}{
+		{"add converts to the first unit", Quantity.Add, parse("3 m"), parse("50 cm"), "3.5 m"},
+		{"subtract", Quantity.Subtract, parse("1 h"), parse("15 min"), "0.75 h"},
+		{"same temperature unit", Quantity.Add, parse("20 °C"), parse("5 degC"), "25 °C"},
+		{"multiply", Quantity.Multiply, parse("3 m"), parse("2 s"), "6 m*s"},
+		{"divide", Quantity.Divide, parse("100 km"), parse("2 h"), "50 km/h"},
+		{"divide to a number", Quantity.Divide, parse("3 m"), parse("50 cm"), "6"},
+		{"plain numbers", Quantity.Multiply, parse("2 kg"), Quantity{Value: 3}, "6 kg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.op(tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got.String() != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+
+	// Derived units keep their dimension through conversion
+	speed, _ := parse("100 km").Divide(parse("2 h"))
+	metres, _ := speed.Multiply(parse("36 s"))
+	if got, err := units.Convert(metres, "m"); err != nil || math.Abs(got.Value-500) > 1e-9 {
+		t.Errorf("Expected 500 m, got %v %v", got, err)
+	}
+
+	errorTests := []struct {
+		name     string
+		op       func(Quantity, Quantity) (Quantity, error)
+		a, b     Quantity
+		expected error
+	}{
+		{"add length and time", Quantity.Add, parse("3 m"), parse("2 s"), ErrIncompatibleUnits},
+		{"subtract mass and a number", Quantity.Subtract, parse("3 kg"), Quantity{Value: 1}, ErrIncompatibleUnits},
+		{"mixed temperature scales", Quantity.Add, parse("20 °C"), parse("5 °F"), ErrIncompatibleUnits},
+		{"multiply a temperature", Quantity.Multiply, parse("20 °C"), parse("2 s"), ErrIncompatibleUnits},
+		{"divide by zero", Quantity.Divide, parse("3 m"), parse("0 s"), ErrDivisionByZero},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.op(tt.a, tt.b); !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+
+	_, err := parse("3 m").Add(parse("2 s"))
+	if err == nil || err.Error() != "incompatible units: cannot add m and s" {
+		t.Errorf("Expected the units in the error, got %v", err)
+	}
+}
+
+func TestCurrency(t *testing.T) {
+	rates, err := LoadRates("testdata/rates.json")
+	if err != nil {
+		t.Fatalf("Failed to load rates: %v", err)
+	}
+	if rates.Base != "EUR" || rates.Date != "2025-06-02" || len(rates.Rates) != 3 {
+		t.Errorf("Unexpected rates: %+v", rates)
+	}
+
+	units := NewUnits()
+	if _, err := units.Parse("10 USD"); !errors.Is(err, ErrUnknownUnit) {
+		t.Errorf("Expected currencies to be unknown before rates are added, got %v", err)
+	}
+	if err := units.AddRates(rates); err != nil {
+		t.Fatalf("Failed to add rates: %v", err)
+	}
+
+	usd, _ := units.Parse("10 USD")
+	tests := []struct {
+		to       string
+		expected float64
+	}{
+		{"EUR", 8},
+		{"GBP", 6.4},
+		{"JPY", 1280},
+	}
+	for _, tt := range tests {
+		got, err := units.Convert(usd, tt.to)
+		if err != nil || math.Abs(got.Value-tt.expected) > 1e-9 {
+			t.Errorf("Expected %v %s, got %v %v", tt.expected, tt.to, got, err)
+		}
+	}
+
+	eur, _ := units.Parse("2 EUR")
+	if sum, err := usd.Add(eur); err != nil || sum.String() != "12.5 USD" {
+		t.Errorf("Expected 12.5 USD, got %v %v", sum, err)
+	}
+	if _, err := usd.Add(Quantity{Value: 1, Unit: Unit{Symbol: "m", Dim: Dimension{DimLength: 1}, Factor: 1}}); !errors.Is(err, ErrIncompatibleUnits) {
+		t.Errorf("Expected ErrIncompatibleUnits, got %v", err)
+	}
+
+	// A new table replaces the old currencies
+	units.AddRates(Rates{Base: "USD", Rates: map[string]float64{"CHF": 0.9}})
+	if _, err := units.Lookup("GBP"); !errors.Is(err, ErrUnknownUnit) {
+		t.Errorf("Expected GBP to be gone, got %v", err)
+	}
+
+	for _, invalid := range []string{
+		`{"base": "EUR", "rates": {"USD": 0}}`,
+		`{"base": "euro", "rates": {}}`,
+		`{"base": "EUR", "rates": {"US": 1.1}}`,
+		`{"base": "EUR", "rates": {"EUR": 2}}`,
+		`not json`,
+	} {
+		if _, err := ParseRates(strings.NewReader(invalid)); !errors.Is(err, ErrInvalidRates) {
+			t.Errorf("Expected ErrInvalidRates for %s, got %v", invalid, err)
+		}
+	}
+	for _, rate := range []float64{math.NaN(), math.Inf(1)} {
+		if err := units.AddRates(Rates{Base: "USD", Rates: map[string]float64{"CHF": rate}}); !errors.Is(err, ErrInvalidRates) {
+			t.Errorf("Expected ErrInvalidRates for rate %v, got %v", rate, err)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package taskmanager
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// Run with -race to catch unsynchronized access
+
+func TestConcurrentAddTaskAllocatesUniqueIDs(t *testing.T) {
+	const workers, perWorker = 8, 50
+
+	for _, factory := range storeFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			tm := NewTaskManagerWithStore(factory.new(t))
+
+			ids := make(chan int, workers*perWorker)
+			var wg sync.WaitGroup
+			for w := 0; w < workers; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					last := 0
+					for i := 0; i < perWorker; i++ {
+						task, err := tm.AddTask(fmt.Sprintf("task %d-%d", w, i), "")
+						if err != nil {
+							t.Errorf("Failed to add task: %v", err)
+							return
+						}
+						// IDs seen by one goroutine must keep increasing
+						if task.ID <= last {
+							t.Errorf("ID %d allocated after %d", task.ID, last)
+						}
+						last = task.ID
+						ids <- task.ID
+					}
+				}(w)
+			}
+			wg.Wait()
+			close(ids)
+
+			seen := make(map[int]bool)
+			for id := range ids {
+				if seen[id] {
+					t.Errorf("ID %d allocated twice", id)
+				}
+				seen[id] = true
+			}
+			for id := 1; id <= workers*perWorker; id++ {
+				if !seen[id] {
+					t.Errorf("ID %d was skipped", id)
+				}
+			}
+		})
+	}
+}
+
+func TestConcurrentMixedOperations(t *testing.T) {
+	const workers, rounds = 8, 30
+
+	for _, factory := range storeFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			tm := NewTaskManagerWithStore(factory.new(t))
+
+			var wg sync.WaitGroup
+			for w := 0; w < workers; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					for i := 0; i < rounds; i++ {
+						task, err := tm.AddTask(fmt.Sprintf("task %d-%d", w, i), "")
+						if err != nil {
+							t.Errorf("Failed to add task: %v", err)
+							return
+						}
+						if _, err := tm.UpdateTask(task.ID, TaskPatch{Description: ptr("updated"), Done: ptr(true)}); err != nil {
+							t.Errorf("Failed to update task %d: %v", task.ID, err)
+						}
+						tm.GetTask(task.ID)
+						tm.ListTasks(nil)
+						if i%2 == 0 {
+							if _, err := tm.DeleteTask(task.ID); err != nil {
+								t.Errorf("Failed to delete task %d: %v", task.ID, err)
+							}
+						}
+					}
+				}(w)
+			}
+			wg.Wait()
+
+			tasks := tm.ListTasks(nil)
+			if len(tasks) != workers*rounds/2 {
+				t.Errorf("Expected %d tasks to remain, got %d", workers*rounds/2, len(tasks))
+			}
+			for _, task := range tasks {
+				if !task.Done || task.Description != "updated" {
+					t.Errorf("Task %d lost its update: %+v", task.ID, task)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkStores compares the single-mutex MemoryStore with ShardedMemoryStore
+// under a parallel read-heavy workload
+func BenchmarkStores(b *testing.B) {
+	stores := []struct {
+		name string
+		new  func() TaskStore
+	}{
+		{name: "mutex", new: func() TaskStore { return NewMemoryStore() }},
+		{name: "sharded", new: func() TaskStore { return NewShardedMemoryStore(DefaultShards) }},
+	}
+
+	for _, store := range stores {
+		b.Run(store.name, func(b *testing.B) {
+			tm := NewTaskManagerWithStore(store.new())
+			for i := 0; i < 1000; i++ {
+				tm.AddTask("seed", "")
+			}
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					i++
+					id := i%1000 + 1
+					switch i % 10 {
+					case 0:
+						tm.AddTask("bench", "")
+					case 1:
+						tm.UpdateTask(id, TaskPatch{Done: ptr(i%2 == 0)})
+					default:
+						tm.GetTask(id)
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package taskmanager
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// Dependency errors
+var (
+	ErrBlockerNotFound = errors.New("blocking task not found")
+	ErrDependencyCycle = errors.New("dependency would create a cycle")
+)
+
+// AddBlocker makes task id wait for task blockerID. Returns ErrBlockerNotFound
+// if the blocker does not exist and ErrDependencyCycle if the blocker already
+// waits for id, directly or through other tasks.
+func (tm *TaskManager) AddBlocker(id, blockerID int) (Task, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	task, err := tm.store.Get(id)
+	if err != nil {
+		return Task{}, err
+	}
+	blockers := append(task.BlockedBy, blockerID)
+	return tm.updateTask(id, TaskPatch{BlockedBy: &blockers})
+}
+
+// RemoveBlocker stops task id from waiting for task blockerID
+func (tm *TaskManager) RemoveBlocker(id, blockerID int) (Task, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	task, err := tm.store.Get(id)
+	if err != nil {
+		return Task{}, err
+	}
+	blockers := slices.DeleteFunc(task.BlockedBy, func(blocker int) bool { return blocker == blockerID })
+	return tm.updateTask(id, TaskPatch{BlockedBy: &blockers})
+}
+
+// Blocks returns the tasks that wait for task id, ordered by ID
+func (tm *TaskManager) Blocks(id int) ([]Task, error) {
+	if _, err := tm.store.Get(id); err != nil {
+		return nil, err
+	}
+	all, err := tm.store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	blocked := []Task{}
+	for _, task := range all {
+		if slices.Contains(task.BlockedBy, id) {
+			blocked = append(blocked, task)
+		}
+	}
+	return blocked, nil
+}
+
+// ReadyTasks returns the open tasks whose blockers are all done, ordered by ID
+func (tm *TaskManager) ReadyTasks() ([]Task, error) {
+	all, err := tm.store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]Task, len(all))
+	for _, task := range all {
+		byID[task.ID] = task
+	}
+	ready := []Task{}
+	for _, task := range all {
+		if !task.Done && !isBlocked(task, byID) {
+			ready = append(ready, task)
+		}
+	}
+	return ready, nil
+}
+
+// Plan returns the open tasks in an order that puts every task after its
+// blockers. Among tasks that are free at the same point, higher priority
+// comes first, then the earlier due date, then the lower ID.
+func (tm *TaskManager) Plan() ([]Task, error) {
+	all, err := tm.store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	// Kahn's algorithm over the open tasks; done blockers are already satisfied
+	open := map[int]Task{}
+	for _, task := range all {
+		if !task.Done {
+			open[task.ID] = task
+		}
+	}
+	waiting := map[int]int{}
+	dependents := map[int][]int{}
+	var free []Task
+	for _, task := range open {
+		for _, blocker := range task.BlockedBy {
+			if _, ok := open[blocker]; ok {
+				waiting[task.ID]++
+				dependents[blocker] = append(dependents[blocker], task.ID)
+			}
+		}
+		if waiting[task.ID] == 0 {
+			free = append(free, task)
+		}
+	}
+
+	plan := make([]Task, 0, len(open))
+	for len(free) > 0 {
+		next := slices.MinFunc(free, planOrder)
+		free = slices.DeleteFunc(free, func(t Task) bool { return t.ID == next.ID })
+		plan = append(plan, next)
+
+		for _, id := range dependents[next.ID] {
+			if waiting[id]--; waiting[id] == 0 {
+				free = append(free, open[id])
+			}
+		}
+	}
+	if len(plan) != len(open) {
+		return nil, ErrDependencyCycle
+	}
+	return plan, nil
+}
+
+// planOrder orders tasks that are free at the same time
+func planOrder(a, b Task) int {
+	if a.Priority != b.Priority {
+		return int(b.Priority) - int(a.Priority)
+	}
+	switch {
+	case a.DueDate != nil && b.DueDate != nil:
+		if c := a.DueDate.Compare(*b.DueDate); c != 0 {
+			return c
+		}
+	case a.DueDate != nil:
+		return -1
+	case b.DueDate != nil:
+		return 1
+	}
+	return a.ID - b.ID
+}
+
+// isBlocked reports whether any blocker of task is still open
+func isBlocked(task Task, byID map[int]Task) bool {
+	for _, blocker := range task.BlockedBy {
+		if t, ok := byID[blocker]; ok && !t.Done {
+			return true
+		}
+	}
+	return false
+}
+
+// checkBlockers verifies that every blocker of task exists and that none of
+// them waits for task, directly or transitively
+func (tm *TaskManager) checkBlockers(task Task) error {
+	if len(task.BlockedBy) == 0 {
+		return nil
+	}
+	// A new task has no ID yet, so 0 must not be mistaken for the task itself
+	if slices.ContainsFunc(task.BlockedBy, func(id int) bool { return id <= 0 }) {
+		return ErrBlockerNotFound
+	}
+
+	// Walk the blockers' own blockers; reaching the task itself means a cycle
+	seen := map[int]bool{}
+	queue := slices.Clone(task.BlockedBy)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if id == task.ID {
+			return ErrDependencyCycle
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		blocker, err := tm.store.Get(id)
+		if errors.Is(err, ErrTaskNotFound) {
+			if slices.Contains(task.BlockedBy, id) {
+				return ErrBlockerNotFound
+			}
+			// A stale edge further up cannot lead back to the task
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		queue = append(queue, blocker.BlockedBy...)
+	}
+	return nil
+}
+
+// unlinkBlocker works out the changes that remove id from the blockers of
+// every task that waits for it, without storing them. It also returns the open
+// tasks that id held back and that are left with no open blockers.
+func (tm *TaskManager) unlinkBlocker(id int, now time.Time) (command, []Task, error) {
+	all, err := tm.store.List()
+	if err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[int]Task, len(all))
+	for _, task := range all {
+		byID[task.ID] = task
+	}
+
+	var (
+		cmd       command
+		unblocked = []Task{}
+	)
+	for _, task := range all {
+		if !slices.Contains(task.BlockedBy, id) {
+			continue
+		}
+		before := task.clone()
+		task.BlockedBy = slices.DeleteFunc(task.BlockedBy, func(blocker int) bool { return blocker == id })
+		if len(task.BlockedBy) == 0 {
+			task.BlockedBy = nil
+		}
+		task.UpdatedAt = now
+		cmd = append(cmd, change{before: &before, after: &task})
+
+		// A done blocker was not holding the task back
+		if !byID[id].Done && !task.Done && !isBlocked(task, byID) {
+			unblocked = append(unblocked, task.clone())
+		}
+	}
+	return cmd, unblocked, nil
+}
+
+// normalizeBlockers sorts blocker IDs and drops duplicates
+func normalizeBlockers(ids []int) []int {
+	if len(ids) == 0 {
+		return nil
+	}
+	ids = slices.Clone(ids)
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
@@ -0,0 +1,166 @@
+package taskmanager
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// newDepsManager returns a manager where 1 "Design" blocks 2 "Build", which
+// blocks 3 "Ship"; 4 "Docs" waits for 1 too
+func newDepsManager(t *testing.T) *TaskManager {
+	t.Helper()
+
+	tm := newTestManager()
+	tm.AddTask("Design", "")
+	tm.AddTask("Build", "", WithBlockers(1))
+	tm.AddTask("Ship", "", WithBlockers(2), WithPriority(PriorityHigh))
+	if _, err := tm.AddTask("Docs", "", WithBlockers(1, 1)); err != nil {
+		t.Fatalf("Failed to add task: %v", err)
+	}
+	return tm
+}
+
+func TestBlockers(t *testing.T) {
+	tm := newDepsManager(t)
+
+	tests := []struct {
+		name    string
+		id      int
+		blocker int
+		err     error
+	}{
+		{name: "self", id: 1, blocker: 1, err: ErrDependencyCycle},
+		{name: "direct cycle", id: 1, blocker: 2, err: ErrDependencyCycle},
+		{name: "transitive cycle", id: 1, blocker: 3, err: ErrDependencyCycle},
+		{name: "unknown blocker", id: 3, blocker: 99, err: ErrBlockerNotFound},
+		{name: "unknown task", id: 99, blocker: 1, err: ErrTaskNotFound},
+		{name: "second path", id: 3, blocker: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tm.AddBlocker(tt.id, tt.blocker); err != tt.err {
+				t.Errorf("Expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+
+	for _, blocker := range []int{99, 0, -1} {
+		if _, err := tm.AddTask("Loop", "", WithBlockers(blocker)); err != ErrBlockerNotFound {
+			t.Errorf("Expected ErrBlockerNotFound for a new task waiting for %d, got %v", blocker, err)
+		}
+	}
+	if _, err := tm.UpdateTask(2, TaskPatch{BlockedBy: &[]int{3}}); err != ErrDependencyCycle {
+		t.Errorf("Expected ErrDependencyCycle from a patch, got %v", err)
+	}
+
+	ship, _ := tm.GetTask(3)
+	if !slices.Equal(ship.BlockedBy, []int{2, 4}) {
+		t.Errorf("Expected blockers [2 4], got %v", ship.BlockedBy)
+	}
+	if blocked, _ := tm.Blocks(1); !slices.Equal(ids(blocked), []int{2, 4}) {
+		t.Errorf("Expected 1 to block [2 4], got %v", ids(blocked))
+	}
+
+	ship, _ = tm.RemoveBlocker(3, 4)
+	if !slices.Equal(ship.BlockedBy, []int{2}) {
+		t.Errorf("Expected blockers [2], got %v", ship.BlockedBy)
+	}
+}
+
+func TestReadyTasksAndPlan(t *testing.T) {
+	tm := newDepsManager(t)
+	tm.AddTask("Unrelated", "", WithPriority(PriorityLow))
+
+	ready, _ := tm.ReadyTasks()
+	if got := ids(ready); !slices.Equal(got, []int{1, 5}) {
+		t.Errorf("Expected ready tasks [1 5], got %v", got)
+	}
+	plan, err := tm.Plan()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := ids(plan); !slices.Equal(got, []int{5, 1, 2, 3, 4}) {
+		t.Errorf("Expected plan [5 1 2 3 4], got %v", got)
+	}
+
+	// Finishing a blocker frees what waits for it and drops it from the plan
+	tm.UpdateTask(1, TaskPatch{Done: ptr(true)})
+	ready, _ = tm.ReadyTasks()
+	if got := ids(ready); !slices.Equal(got, []int{2, 4, 5}) {
+		t.Errorf("Expected ready tasks [2 4 5], got %v", got)
+	}
+	plan, _ = tm.Plan()
+	if got := ids(plan); !slices.Equal(got, []int{5, 2, 3, 4}) {
+		t.Errorf("Expected plan [5 2 3 4], got %v", got)
+	}
+}
+
+func TestDeleteTaskUnblocks(t *testing.T) {
+	tm := newDepsManager(t)
+	tm.AddBlocker(4, 3)
+
+	unblocked, err := tm.DeleteTask(1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := ids(unblocked); !slices.Equal(got, []int{2}) {
+		t.Errorf("Expected only task 2 to be unblocked, got %v", got)
+	}
+	for _, id := range []int{2, 4} {
+		task, _ := tm.GetTask(id)
+		if slices.Contains(task.BlockedBy, 1) {
+			t.Errorf("Expected task %d to no longer wait for 1, got %v", id, task.BlockedBy)
+		}
+	}
+
+	// Undo brings the edges back along with the task
+	if err := tm.Undo(); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if build, _ := tm.GetTask(2); !slices.Equal(build.BlockedBy, []int{1}) {
+		t.Errorf("Expected task 2 to wait for 1 again, got %v", build.BlockedBy)
+	}
+
+	// A task restored from the trash forgets blockers deleted meanwhile
+	tm.DeleteTask(4)
+	tm.DeleteTask(3)
+	docs, err := tm.RestoreTask(4)
+	if err != nil || !slices.Equal(docs.BlockedBy, []int{1}) {
+		t.Errorf("Expected task 4 back waiting for 1 only, got %v %v", docs.BlockedBy, err)
+	}
+
+	// A done blocker was not holding anything back
+	review, _ := tm.AddTask("Review", "")
+	tm.AddTask("Merge", "", WithBlockers(review.ID))
+	tm.UpdateTask(review.ID, TaskPatch{Done: ptr(true)})
+	if unblocked, err := tm.DeleteTask(review.ID); err != nil || len(unblocked) != 0 {
+		t.Errorf("Expected nothing to be unblocked by deleting a done task, got %v %v", ids(unblocked), err)
+	}
+}
+
+func TestDeleteTaskIsAtomic(t *testing.T) {
+	// The task is trashed by the first write and its dependents unlinked by the next two
+	for _, failAt := range []int{1, 3} {
+		tm, store := newFailingManager()
+		tm.AddTask("Design", "")
+		tm.AddTask("Build", "", WithBlockers(1))
+		tm.AddTask("Docs", "", WithBlockers(1))
+
+		store.failNext(failAt)
+		if _, err := tm.DeleteTask(1); !errors.Is(err, errWriteFailed) {
+			t.Fatalf("Expected write %d to fail, got %v", failAt, err)
+		}
+		if _, err := tm.GetTask(1); err != nil {
+			t.Errorf("Expected task 1 to be kept after write %d failed, got %v", failAt, err)
+		}
+		for _, id := range []int{2, 3} {
+			if task, _ := tm.GetTask(id); !slices.Equal(task.BlockedBy, []int{1}) {
+				t.Errorf("Expected task %d to still wait for 1 after write %d failed, got %v", id, failAt, task.BlockedBy)
+			}
+		}
+		if trash := tm.Trash(); len(trash) != 0 {
+			t.Errorf("Expected an empty trash after write %d failed, got %v", failAt, trash)
+		}
+	}
+}
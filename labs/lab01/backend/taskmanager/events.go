@@ -0,0 +1,105 @@
+package taskmanager
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SubscriberBuffer is the number of events a subscriber may fall behind
+// before it is dropped
+const SubscriberBuffer = 64
+
+// EventType says what happened to a task
+type EventType string
+
+// Event types
+const (
+	EventCreated EventType = "created"
+	EventUpdated EventType = "updated"
+	EventDeleted EventType = "deleted"
+)
+
+// TaskEvent describes one change to a task. Before is nil for EventCreated
+// and After is nil for EventDeleted.
+type TaskEvent struct {
+	Type   EventType `json:"type"`
+	Before *Task     `json:"before,omitempty"`
+	After  *Task     `json:"after,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// eventBus fans events out to subscribers without ever blocking the sender
+type eventBus struct {
+	mu   sync.Mutex
+	subs map[chan TaskEvent]struct{}
+}
+
+// Subscribe returns a channel that receives an event for every change made
+// through this manager, including undo, redo, restores and imports. The
+// channel is closed when ctx is done. A subscriber that falls more than
+// SubscriberBuffer events behind is dropped and its channel closed, so
+// writers never wait for it; it should re-subscribe and reload its tasks.
+func (tm *TaskManager) Subscribe(ctx context.Context) <-chan TaskEvent {
+	ch := make(chan TaskEvent, SubscriberBuffer)
+
+	bus := &tm.events
+	bus.mu.Lock()
+	if bus.subs == nil {
+		bus.subs = make(map[chan TaskEvent]struct{})
+	}
+	bus.subs[ch] = struct{}{}
+	bus.mu.Unlock()
+
+	context.AfterFunc(ctx, func() {
+		bus.mu.Lock()
+		defer bus.mu.Unlock()
+		bus.remove(ch)
+	})
+	return ch
+}
+
+// publish sends an event for a change from before to after to every subscriber
+func (tm *TaskManager) publish(before, after *Task, at time.Time) {
+	bus := &tm.events
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	if len(bus.subs) == 0 {
+		return
+	}
+
+	event := TaskEvent{Type: EventUpdated, At: at}
+	switch {
+	case before == nil:
+		event.Type = EventCreated
+	case after == nil:
+		event.Type = EventDeleted
+	}
+
+	for ch := range bus.subs {
+		// Each subscriber gets its own copies
+		if before != nil {
+			b := before.clone()
+			event.Before = &b
+		}
+		if after != nil {
+			a := after.clone()
+			event.After = &a
+		}
+
+		select {
+		case ch <- event:
+		default:
+			bus.remove(ch)
+		}
+	}
+}
+
+// remove closes ch unless it was already removed; the caller holds mu
+func (b *eventBus) remove(ch chan TaskEvent) {
+	if _, ok := b.subs[ch]; ok {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
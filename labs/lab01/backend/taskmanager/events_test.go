@@ -0,0 +1,102 @@
+package taskmanager
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// receive reads the next event, failing the test if none arrives in time
+func receive(t *testing.T, events <-chan TaskEvent) TaskEvent {
+	t.Helper()
+
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("Channel closed unexpectedly")
+		}
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for an event")
+	}
+	return TaskEvent{}
+}
+
+func TestSubscribeReceivesChanges(t *testing.T) {
+	tm := newTestManager()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := tm.Subscribe(ctx)
+
+	task, _ := tm.AddTask("Draft", "")
+	tm.UpdateTask(task.ID, TaskPatch{Title: ptr("Final")})
+	tm.DeleteTask(task.ID)
+	tm.Undo()
+	tm.Import(strings.NewReader(`[{"title": "Imported"}]`), FormatJSON, ImportAppend)
+
+	expected := []struct {
+		typ    EventType
+		before string
+		after  string
+	}{
+		{typ: EventCreated, after: "Draft"},
+		{typ: EventUpdated, before: "Draft", after: "Final"},
+		{typ: EventDeleted, before: "Final"},
+		{typ: EventCreated, after: "Final"},
+		{typ: EventCreated, after: "Imported"},
+	}
+	for i, want := range expected {
+		event := receive(t, events)
+		if event.Type != want.typ || event.At.IsZero() {
+			t.Errorf("Event %d: expected %s, got %+v", i, want.typ, event)
+		}
+		if (event.Before == nil) != (want.before == "") || (event.Before != nil && event.Before.Title != want.before) {
+			t.Errorf("Event %d: expected before %q, got %+v", i, want.before, event.Before)
+		}
+		if (event.After == nil) != (want.after == "") || (event.After != nil && event.After.Title != want.after) {
+			t.Errorf("Event %d: expected after %q, got %+v", i, want.after, event.After)
+		}
+	}
+
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("Expected no more events")
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected the channel to close when the context is done")
+	}
+}
+
+func TestSlowSubscriberDoesNotBlockWriters(t *testing.T) {
+	tm := newTestManager()
+	slow := tm.Subscribe(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fast := tm.Subscribe(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < SubscriberBuffer*2; i++ {
+			tm.AddTask("Task", "")
+			<-fast
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Writers blocked on a slow subscriber")
+	}
+
+	// The slow subscriber keeps what fitted in its buffer, then its channel is closed
+	count := 0
+	for range slow {
+		count++
+	}
+	if count != SubscriberBuffer {
+		t.Errorf("Expected %d buffered events, got %d", SubscriberBuffer, count)
+	}
+}
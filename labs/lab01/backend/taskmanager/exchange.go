@@ -0,0 +1,731 @@
+package taskmanager
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Exchange errors
+var (
+	ErrUnknownFormat     = errors.New("unknown format")
+	ErrUnknownImportMode = errors.New("unknown import mode")
+	ErrMissingColumn     = errors.New("missing required column")
+)
+
+// Format is a file format for Export and Import
+type Format string
+
+// Supported formats
+const (
+	FormatJSON Format = "json"
+	FormatCSV  Format = "csv"
+	FormatICS  Format = "ics"
+)
+
+// ImportMode decides what happens to existing tasks during Import
+type ImportMode string
+
+// Import modes
+const (
+	// ImportAppend adds every row as a new task; existing tasks are untouched
+	ImportAppend ImportMode = "append"
+	// ImportMerge updates existing tasks whose ID matches a row with the fields
+	// the row sets, and adds the other rows
+	ImportMerge ImportMode = "merge"
+	// ImportReplace moves every existing task to the trash, then adds the rows
+	// under the IDs they have in the file
+	ImportReplace ImportMode = "replace"
+)
+
+// RowError reports why one row of an import was skipped. Rows are numbered
+// from 1 in file order: array elements, CSV data rows or VTODO components.
+type RowError struct {
+	Row int
+	Err error
+}
+
+// Error implements error
+func (e RowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+// Unwrap lets errors.Is match the underlying error, e.g. ErrEmptyTitle
+func (e RowError) Unwrap() error {
+	return e.Err
+}
+
+// ImportResult summarizes an import
+type ImportResult struct {
+	Created int
+	Updated int
+	Errors  []RowError
+}
+
+// Err joins the row errors, or returns nil if every row was imported
+func (r ImportResult) Err() error {
+	errs := make([]error, len(r.Errors))
+	for i, err := range r.Errors {
+		errs[i] = err
+	}
+	return errors.Join(errs...)
+}
+
+// importRow is a decoded row waiting to be stored. ref identifies the row
+// within the file; parentRef and blockerRefs point at other rows' refs or at
+// existing task IDs. fields names the csvColumns the row sets, so a merge
+// leaves the others alone.
+type importRow struct {
+	row         int
+	task        Task
+	ref         string
+	parentRef   string
+	blockerRefs []string
+	fields      map[string]bool
+}
+
+// csvColumns is the header written by Export; Import accepts them in any order
+var csvColumns = []string{
+	"id", "title", "description", "done", "priority", "tags", "due_date",
+	"recurrence", "parent_id", "blocked_by", "created_at", "updated_at", "completed_at",
+}
+
+// Export writes every task in the given format
+func (tm *TaskManager) Export(w io.Writer, format Format) error {
+	tasks, err := tm.store.List()
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(tasks)
+	case FormatCSV:
+		return exportCSV(w, tasks)
+	case FormatICS:
+		return exportICS(w, tasks, tm.now())
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+}
+
+// Import reads tasks in the given format. Rows that fail validation are
+// reported in the result and skipped, and rows whose blockers cannot be linked
+// are imported without them and reported too. The error is only set when the
+// file cannot be read at all, in which case nothing is changed, or when the
+// store fails. The whole import is one command for Undo.
+func (tm *TaskManager) Import(r io.Reader, format Format, mode ImportMode) (ImportResult, error) {
+	if mode != ImportAppend && mode != ImportMerge && mode != ImportReplace {
+		return ImportResult{}, fmt.Errorf("%w: %q", ErrUnknownImportMode, mode)
+	}
+
+	var (
+		rows    []importRow
+		rowErrs []RowError
+		err     error
+	)
+	switch format {
+	case FormatJSON:
+		rows, rowErrs, err = decodeJSON(r)
+	case FormatCSV:
+		rows, rowErrs, err = decodeCSV(r)
+	case FormatICS:
+		rows, rowErrs, err = decodeICS(r)
+	default:
+		err = fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	result := ImportResult{Errors: rowErrs}
+	now := tm.now()
+	imp := &importer{tm: tm, mode: mode, now: now}
+	// Record whatever was changed, even if the store fails halfway, so it can be undone
+	defer func() {
+		if len(imp.cmd) > 0 {
+			tm.record(imp.cmd, now)
+		}
+	}()
+	if mode == ImportReplace {
+		if err := imp.trashAll(); err != nil {
+			return ImportResult{}, err
+		}
+	}
+
+	inFile := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		if row.ref != "" {
+			inFile[row.ref] = true
+		}
+	}
+
+	// Store rows once their parent is stored; whatever is left has a missing or cyclic parent
+	stored := map[string]int{}
+	var linked []importRow
+	for pending := rows; len(pending) > 0; {
+		var waiting []importRow
+		for _, row := range pending {
+			parentID, ready := resolveParent(row, inFile, stored)
+			if !ready {
+				waiting = append(waiting, row)
+				continue
+			}
+			row.task.ParentID = parentID
+
+			id, updated, err := imp.store(row)
+			if err != nil {
+				result.Errors = append(result.Errors, RowError{Row: row.row, Err: err})
+				continue
+			}
+			if row.ref != "" {
+				stored[row.ref] = id
+			}
+			if len(row.blockerRefs) > 0 {
+				row.task.ID = id
+				linked = append(linked, row)
+			}
+			if updated {
+				result.Updated++
+			} else {
+				result.Created++
+			}
+		}
+
+		if len(waiting) == len(pending) {
+			for _, row := range waiting {
+				result.Errors = append(result.Errors, RowError{Row: row.row, Err: ErrParentNotFound})
+			}
+			break
+		}
+		pending = waiting
+	}
+
+	// Blockers may come later in the file, so they are linked once every row is stored
+	for _, row := range linked {
+		if err := imp.link(row, inFile, stored); err != nil {
+			result.Errors = append(result.Errors, RowError{Row: row.row, Err: err})
+		}
+	}
+
+	// Report errors top to bottom
+	slices.SortStableFunc(result.Errors, func(a, b RowError) int { return a.Row - b.Row })
+	return result, nil
+}
+
+// importer stores the rows of one import and collects the changes into a
+// single command. The caller holds tm.mu.
+type importer struct {
+	tm   *TaskManager
+	mode ImportMode
+	now  time.Time
+	cmd  command
+	// trashed holds the IDs in the trash before the import; rows cannot take them over
+	trashed map[int]bool
+}
+
+// trashAll moves every task to the trash, children before their parents so no
+// live task ever points at a trashed parent
+func (imp *importer) trashAll() error {
+	tm := imp.tm
+	trashed, err := tm.store.Trashed()
+	if err != nil {
+		return err
+	}
+	imp.trashed = make(map[int]bool, len(trashed))
+	for _, deleted := range trashed {
+		imp.trashed[deleted.Task.ID] = true
+	}
+
+	tasks, err := tm.store.List()
+	if err != nil {
+		return err
+	}
+
+	for len(tasks) > 0 {
+		parents := map[int]bool{}
+		for _, task := range tasks {
+			parents[task.ParentID] = true
+		}
+
+		var remaining []Task
+		for _, task := range tasks {
+			if parents[task.ID] {
+				remaining = append(remaining, task)
+				continue
+			}
+			if err := tm.store.Trash(task.ID, imp.now); err != nil {
+				return err
+			}
+			imp.cmd = append(imp.cmd, change{before: &task, trash: true})
+		}
+		if len(remaining) == len(tasks) {
+			return errors.New("cannot trash tasks: parent links form a cycle")
+		}
+		tasks = remaining
+	}
+	return nil
+}
+
+// resolveParent maps a row's parent reference to a task ID. It is not ready
+// while the parent is another row of the file that has not been stored yet.
+// References to rows that failed are never resolved.
+func resolveParent(row importRow, inFile map[string]bool, stored map[string]int) (int, bool) {
+	if row.parentRef == "" {
+		return 0, true
+	}
+	if inFile[row.parentRef] {
+		id, ok := stored[row.parentRef]
+		return id, ok
+	}
+
+	// Not in the file: treat it as the ID of an existing task and let checkParent verify it
+	return refID(row.parentRef), true
+}
+
+// refID reads a reference to an existing task, either a plain ID or a UID
+// written by Export, returning -1 if it is neither
+func refID(ref string) int {
+	id, err := strconv.Atoi(ref)
+	if err != nil {
+		id, _ = icsTaskID(ref)
+	}
+	if id <= 0 {
+		return -1
+	}
+	return id
+}
+
+// link sets the blockers of a stored row. References to other rows of the
+// file map to the IDs they were stored under.
+func (imp *importer) link(row importRow, inFile map[string]bool, stored map[string]int) error {
+	tm := imp.tm
+	blockers := make([]int, 0, len(row.blockerRefs))
+	for _, ref := range row.blockerRefs {
+		id := refID(ref)
+		if inFile[ref] {
+			var ok bool
+			if id, ok = stored[ref]; !ok {
+				return ErrBlockerNotFound
+			}
+		}
+		if id < 0 {
+			return ErrBlockerNotFound
+		}
+		blockers = append(blockers, id)
+	}
+
+	task, err := tm.store.Get(row.task.ID)
+	if err != nil {
+		return err
+	}
+	before := task.clone()
+	task.BlockedBy = normalizeBlockers(blockers)
+	if err := tm.checkBlockers(task); err != nil {
+		return err
+	}
+	if err := tm.store.Update(task); err != nil {
+		return err
+	}
+	imp.cmd = append(imp.cmd, change{before: &before, after: &task})
+	return nil
+}
+
+// store validates and saves one imported row, reporting whether it updated an
+// existing task
+func (imp *importer) store(row importRow) (int, bool, error) {
+	tm, now := imp.tm, imp.now
+	task := row.task
+
+	var existing *Task
+	if imp.mode == ImportMerge && task.ID > 0 {
+		old, err := tm.store.Get(task.ID)
+		if err != nil && !errors.Is(err, ErrTaskNotFound) {
+			return 0, false, err
+		}
+		if err == nil {
+			existing = &old
+			task = mergeTask(old, task, row.fields)
+			if !row.fields["updated_at"] {
+				task.UpdatedAt = now
+			}
+		}
+	}
+
+	task.Tags = normalizeTags(task.Tags)
+	if err := validateTask(task); err != nil {
+		return 0, false, err
+	}
+	if task.ParentID < 0 {
+		return 0, false, ErrParentNotFound
+	}
+
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = now
+	}
+	if task.UpdatedAt.IsZero() {
+		task.UpdatedAt = task.CreatedAt
+	}
+	if !task.Done {
+		task.CompletedAt = nil
+	} else if task.CompletedAt == nil {
+		completed := task.UpdatedAt
+		task.CompletedAt = &completed
+	}
+	// Replace keeps the row's ID unless an older trashed task holds it; other
+	// new tasks get fresh IDs so checkParent cannot mistake the row's ID for an existing task
+	keepID := imp.mode == ImportReplace && task.ID > 0 && !imp.trashed[task.ID]
+	if existing == nil && !keepID {
+		task.ID = 0
+	}
+
+	if task.ParentID != 0 {
+		if err := tm.checkParent(task); err != nil {
+			return 0, false, err
+		}
+	}
+	if existing != nil {
+		if task.Done {
+			open, err := tm.hasOpenSubtasks(task.ID)
+			if err != nil {
+				return 0, false, err
+			}
+			if open {
+				return 0, false, ErrOpenSubtasks
+			}
+		}
+		if err := tm.store.Update(task); err != nil {
+			return 0, false, err
+		}
+		imp.cmd = append(imp.cmd, change{before: existing, after: &task})
+		return task.ID, true, nil
+	}
+
+	if keepID {
+		err := tm.store.Restore(task)
+		if err == nil {
+			imp.cmd = append(imp.cmd, change{after: &task})
+			return task.ID, false, nil
+		}
+		// A duplicate ID in the file: the later row gets a fresh one
+		if !errors.Is(err, ErrTaskExists) {
+			return 0, false, err
+		}
+		task.ID = 0
+	}
+	created, err := tm.store.Create(task)
+	if err != nil {
+		return 0, false, err
+	}
+	imp.cmd = append(imp.cmd, change{after: &created})
+	return created.ID, false, nil
+}
+
+// mergeTask copies the fields a merge row sets onto the task it updates. The
+// creation time is kept, and blockers set by the row are linked later.
+func mergeTask(existing, row Task, fields map[string]bool) Task {
+	task := existing.clone()
+	if fields["title"] {
+		task.Title = row.Title
+	}
+	if fields["description"] {
+		task.Description = row.Description
+	}
+	if fields["done"] {
+		task.Done = row.Done
+	}
+	if fields["priority"] {
+		task.Priority = row.Priority
+	}
+	if fields["tags"] {
+		task.Tags = row.Tags
+	}
+	if fields["due_date"] {
+		task.DueDate = row.DueDate
+	}
+	if fields["recurrence"] {
+		task.Recurrence = row.Recurrence
+	}
+	if fields["parent_id"] {
+		task.ParentID = row.ParentID
+	}
+	if fields["blocked_by"] {
+		task.BlockedBy = nil
+	}
+	if fields["updated_at"] {
+		task.UpdatedAt = row.UpdatedAt
+	}
+	if fields["completed_at"] {
+		task.CompletedAt = row.CompletedAt
+	}
+	return task
+}
+
+// decodeJSON reads an array of tasks in the format written by Export
+func decodeJSON(r io.Reader) ([]importRow, []RowError, error) {
+	var elements []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&elements); err != nil {
+		return nil, nil, fmt.Errorf("decode JSON: %w", err)
+	}
+
+	var (
+		rows    []importRow
+		rowErrs []RowError
+	)
+	for i, element := range elements {
+		var task Task
+		if err := json.Unmarshal(element, &task); err != nil {
+			rowErrs = append(rowErrs, RowError{Row: i + 1, Err: err})
+			continue
+		}
+		// The element decoded as a task, so it is an object or null
+		var keys map[string]json.RawMessage
+		json.Unmarshal(element, &keys)
+		fields := make(map[string]bool, len(keys))
+		for key, value := range keys {
+			// Keys match fields without regard to case, as in json.Unmarshal
+			fields[strings.ToLower(key)] = string(value) != "null"
+		}
+		rows = append(rows, newImportRow(i+1, task, fields))
+	}
+	return rows, rowErrs, nil
+}
+
+// newImportRow uses the task's own ID and parent ID as file references
+func newImportRow(row int, task Task, fields map[string]bool) importRow {
+	r := importRow{row: row, task: task, fields: fields}
+	for _, blocker := range task.BlockedBy {
+		r.blockerRefs = append(r.blockerRefs, strconv.Itoa(blocker))
+	}
+	r.task.BlockedBy = nil
+	if task.ID > 0 {
+		r.ref = strconv.Itoa(task.ID)
+	}
+	if task.ParentID > 0 {
+		r.parentRef = strconv.Itoa(task.ParentID)
+	}
+	return r
+}
+
+func exportCSV(w io.Writer, tasks []Task) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvColumns); err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		record := []string{
+			strconv.Itoa(task.ID),
+			task.Title,
+			task.Description,
+			strconv.FormatBool(task.Done),
+			task.Priority.String(),
+			joinTags(task.Tags),
+			formatOptionalTime(task.DueDate),
+			"",
+			"",
+			"",
+			task.CreatedAt.Format(time.RFC3339Nano),
+			task.UpdatedAt.Format(time.RFC3339Nano),
+			formatOptionalTime(task.CompletedAt),
+		}
+		if task.Recurrence != nil {
+			record[7] = task.Recurrence.String()
+		}
+		if task.ParentID != 0 {
+			record[8] = strconv.Itoa(task.ParentID)
+		}
+		blockers := make([]string, len(task.BlockedBy))
+		for i, blocker := range task.BlockedBy {
+			blockers[i] = strconv.Itoa(blocker)
+		}
+		record[9] = strings.Join(blockers, ",")
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// decodeCSV reads a CSV file with a header row naming the columns. Only title
+// is required; unknown columns are ignored. Tags are comma separated, with
+// tags that contain a comma quoted as in a CSV record.
+func decodeCSV(r io.Reader) ([]importRow, []RowError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("read CSV header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, nil, fmt.Errorf("%w: title", ErrMissingColumn)
+	}
+
+	var (
+		rows    []importRow
+		rowErrs []RowError
+	)
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, nil, fmt.Errorf("read CSV: %w", err)
+			}
+			// A malformed record only spoils its own row
+			rowErrs = append(rowErrs, RowError{Row: row, Err: parseErr.Err})
+			continue
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		task, err := csvTask(field)
+		if err != nil {
+			rowErrs = append(rowErrs, RowError{Row: row, Err: err})
+			continue
+		}
+		// An empty cell sets nothing
+		fields := make(map[string]bool, len(csvColumns))
+		for _, name := range csvColumns {
+			fields[name] = field(name) != ""
+		}
+		rows = append(rows, newImportRow(row, task, fields))
+	}
+	return rows, rowErrs, nil
+}
+
+// csvTask decodes the fields of one CSV row
+func csvTask(field func(string) string) (Task, error) {
+	task := Task{Title: field("title"), Description: field("description")}
+
+	var err error
+	if raw := field("id"); raw != "" {
+		if task.ID, err = strconv.Atoi(raw); err != nil {
+			return Task{}, fmt.Errorf("id: %q is not an integer", raw)
+		}
+	}
+	if raw := field("done"); raw != "" {
+		if task.Done, err = strconv.ParseBool(raw); err != nil {
+			return Task{}, fmt.Errorf("done: %q is not a boolean", raw)
+		}
+	}
+	if raw := field("priority"); raw != "" {
+		if task.Priority, err = ParsePriority(raw); err != nil {
+			return Task{}, err
+		}
+	}
+	if raw := field("tags"); raw != "" {
+		if task.Tags, err = splitTags(raw); err != nil {
+			return Task{}, err
+		}
+	}
+	if raw := field("recurrence"); raw != "" {
+		rule, err := ParseRRule(raw)
+		if err != nil {
+			return Task{}, err
+		}
+		task.Recurrence = &rule
+	}
+	if raw := field("parent_id"); raw != "" {
+		if task.ParentID, err = strconv.Atoi(raw); err != nil {
+			return Task{}, fmt.Errorf("parent_id: %q is not an integer", raw)
+		}
+	}
+	if raw := field("blocked_by"); raw != "" {
+		for _, part := range strings.Split(raw, ",") {
+			blocker, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				return Task{}, fmt.Errorf("blocked_by: %q is not an integer", part)
+			}
+			task.BlockedBy = append(task.BlockedBy, blocker)
+		}
+	}
+
+	times := make(map[string]*time.Time, 4)
+	for _, name := range []string{"due_date", "created_at", "updated_at", "completed_at"} {
+		if times[name], err = parseOptionalTime(name, field(name)); err != nil {
+			return Task{}, err
+		}
+	}
+	task.DueDate = times["due_date"]
+	task.CompletedAt = times["completed_at"]
+	if t := times["created_at"]; t != nil {
+		task.CreatedAt = *t
+	}
+	if t := times["updated_at"]; t != nil {
+		task.UpdatedAt = *t
+	}
+	return task, nil
+}
+
+// joinTags writes tags as one CSV record, so a comma inside a tag survives
+func joinTags(tags []string) string {
+	var b strings.Builder
+	writer := csv.NewWriter(&b)
+	writer.Write(tags)
+	writer.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// splitTags reads tags written by joinTags or as a plain comma separated list
+func splitTags(raw string) ([]string, error) {
+	reader := csv.NewReader(strings.NewReader(raw))
+	reader.TrimLeadingSpace = true
+	// Hand-written lists may contain stray quotes
+	reader.LazyQuotes = true
+	tags, err := reader.Read()
+	if err == nil {
+		_, err = reader.Read()
+		if errors.Is(err, io.EOF) {
+			return tags, nil
+		}
+		err = errors.New("more than one line")
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		err = parseErr.Err
+	}
+	return nil, fmt.Errorf("tags: %w", err)
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+func parseOptionalTime(name, raw string) (*time.Time, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %q is not an RFC 3339 timestamp", name, raw)
+	}
+	return &t, nil
+}
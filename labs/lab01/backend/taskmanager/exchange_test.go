@@ -0,0 +1,385 @@
+package taskmanager
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// newExchangeManager returns a manager with a parent, a done sub-task and a
+// recurring task whose fields exercise every column
+func newExchangeManager(t *testing.T) *TaskManager {
+	t.Helper()
+
+	tm := newTestManager()
+	rule, _ := ParseRRule("FREQ=WEEKLY;BYDAY=MO")
+	parent, err := tm.AddTask("Plan trip", "flights; hotel, \"car\"\nand maps", WithPriority(PriorityHigh), WithTags("travel", "family", "rome, italy", `say "ciao"`), WithDueDate(date(20)))
+	if err != nil {
+		t.Fatalf("Failed to add task: %v", err)
+	}
+	child, _ := tm.AddTask("Book hotel", "", WithParent(parent.ID), WithPriority(PriorityLow))
+	tm.UpdateTask(child.ID, TaskPatch{Done: ptr(true)})
+	tm.AddTask("Water plants, all of them", strings.Repeat("long description, ", 10)+"end", WithRecurrence(rule), WithDueDate(at(2025, 6, 2)), WithBlockers(parent.ID, child.ID))
+	return tm
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	for _, format := range []Format{FormatJSON, FormatCSV, FormatICS} {
+		t.Run(string(format), func(t *testing.T) {
+			source := newExchangeManager(t)
+			var buf bytes.Buffer
+			if err := source.Export(&buf, format); err != nil {
+				t.Fatalf("Export failed: %v", err)
+			}
+
+			target := newTestManager()
+			target.AddTask("Already here", "")
+			target.AddTask("Also here", "")
+			target.AddTask("And here", "")
+			target.AddTask("Not in the file", "")
+			result, err := target.Import(&buf, format, ImportReplace)
+			if err != nil {
+				t.Fatalf("Import failed: %v", err)
+			}
+			if result.Created != 3 || result.Updated != 0 || len(result.Errors) != 0 {
+				t.Fatalf("Expected 3 created, got %+v", result)
+			}
+
+			// Replacing keeps the IDs of the file, so references to tasks survive the round trip
+			if tasks := target.ListTasks(nil); len(tasks) != 3 {
+				t.Errorf("Expected only the imported tasks, got %+v", tasks)
+			}
+			for _, want := range source.ListTasks(nil) {
+				got, err := target.GetTask(want.ID)
+				if err != nil {
+					t.Fatalf("Task %d missing after import: %v", want.ID, err)
+				}
+				if got.Title != want.Title || got.Description != want.Description || got.Done != want.Done || got.Priority != want.Priority {
+					t.Errorf("Expected %+v, got %+v", want, got)
+				}
+				if !slices.Equal(got.Tags, want.Tags) {
+					t.Errorf("Expected tags %v, got %v", want.Tags, got.Tags)
+				}
+				if (got.DueDate == nil) != (want.DueDate == nil) || (got.DueDate != nil && !got.DueDate.Equal(*want.DueDate)) {
+					t.Errorf("Expected due date %v, got %v", want.DueDate, got.DueDate)
+				}
+				if (got.Recurrence == nil) != (want.Recurrence == nil) || (got.Recurrence != nil && got.Recurrence.String() != want.Recurrence.String()) {
+					t.Errorf("Expected recurrence %v, got %v", want.Recurrence, got.Recurrence)
+				}
+				if got.ParentID != want.ParentID {
+					t.Errorf("Expected parent %d, got %d", want.ParentID, got.ParentID)
+				}
+				if !slices.Equal(got.BlockedBy, want.BlockedBy) {
+					t.Errorf("Expected blockers %v, got %v", want.BlockedBy, got.BlockedBy)
+				}
+				if !got.CreatedAt.Equal(want.CreatedAt.Truncate(time.Second)) {
+					t.Errorf("Expected created at %v, got %v", want.CreatedAt, got.CreatedAt)
+				}
+			}
+		})
+	}
+}
+
+func TestImportReportsRowErrors(t *testing.T) {
+	tm := newTestManager()
+	input := strings.Join([]string{
+		"title,priority,parent_id,id,done",
+		"Good,low,,1,",
+		",high,,2,",
+		"Bad priority,urgent,,3,",
+		"Orphan,,99,4,",
+		`"unterminated,,,5,`,
+	}, "\n")
+
+	result, err := tm.Import(strings.NewReader(input), FormatCSV, ImportAppend)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Created != 1 {
+		t.Errorf("Expected 1 created, got %d", result.Created)
+	}
+
+	expected := []struct {
+		row int
+		err error
+	}{
+		{row: 2, err: ErrEmptyTitle},
+		{row: 3, err: ErrInvalidPriority},
+		{row: 4, err: ErrParentNotFound},
+		{row: 5},
+	}
+	if len(result.Errors) != len(expected) {
+		t.Fatalf("Expected %d row errors, got %v", len(expected), result.Errors)
+	}
+	for i, want := range expected {
+		got := result.Errors[i]
+		if got.Row != want.row || (want.err != nil && !errors.Is(got, want.err)) {
+			t.Errorf("Expected row %d error %v, got %v", want.row, want.err, got)
+		}
+	}
+	if !errors.Is(result.Err(), ErrEmptyTitle) {
+		t.Errorf("Expected joined error to wrap ErrEmptyTitle, got %v", result.Err())
+	}
+}
+
+func TestImportModes(t *testing.T) {
+	input := `[
+		{"id": 1, "title": "Renamed"},
+		{"id": 7, "title": "New parent"},
+		{"id": 8, "title": "New child", "parent_id": 7}
+	]`
+
+	tests := []struct {
+		mode     ImportMode
+		created  int
+		updated  int
+		expected []string
+	}{
+		{mode: ImportAppend, created: 3, expected: []string{"First", "Second", "Renamed", "New parent", "New child"}},
+		{mode: ImportMerge, created: 2, updated: 1, expected: []string{"Renamed", "Second", "New parent", "New child"}},
+		{mode: ImportReplace, created: 3, expected: []string{"Renamed", "New parent", "New child"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			tm := newTestManager()
+			first, _ := tm.AddTask("First", "")
+			tm.AddTask("Second", "", WithParent(first.ID))
+
+			result, err := tm.Import(strings.NewReader(input), FormatJSON, tt.mode)
+			if err != nil {
+				t.Fatalf("Import failed: %v", err)
+			}
+			if result.Created != tt.created || result.Updated != tt.updated || len(result.Errors) != 0 {
+				t.Errorf("Expected %d created and %d updated, got %+v", tt.created, tt.updated, result)
+			}
+
+			var titles []string
+			byTitle := map[string]Task{}
+			for _, task := range tm.ListTasks(nil) {
+				titles = append(titles, task.Title)
+				byTitle[task.Title] = task
+			}
+			if !slices.Equal(titles, tt.expected) {
+				t.Errorf("Expected tasks %v, got %v", tt.expected, titles)
+			}
+			if byTitle["New child"].ParentID != byTitle["New parent"].ID {
+				t.Errorf("Expected the child to point at the imported parent, got %+v", byTitle["New child"])
+			}
+		})
+	}
+
+	// Merging must not complete a task whose sub-tasks are still open
+	tm := newTestManager()
+	first, _ := tm.AddTask("First", "")
+	tm.AddTask("Second", "", WithParent(first.ID))
+	result, _ := tm.Import(strings.NewReader(`[{"id": 1, "title": "First", "done": true}]`), FormatJSON, ImportMerge)
+	if len(result.Errors) != 1 || !errors.Is(result.Errors[0], ErrOpenSubtasks) {
+		t.Errorf("Expected ErrOpenSubtasks, got %v", result.Errors)
+	}
+}
+
+func TestImportMergeKeepsFieldsTheRowLeavesOut(t *testing.T) {
+	inputs := []struct {
+		format Format
+		input  string
+	}{
+		{FormatJSON, `[{"id": 1, "title": "Renamed", "done": true, "tags": null}]`},
+		{FormatCSV, "id,title,description,done,tags,due_date,blocked_by\n1,Renamed,,true,,,\n"},
+		{FormatICS, "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:task-1@taskmanager\r\nSUMMARY:Renamed\r\nSTATUS:COMPLETED\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"},
+	}
+
+	for _, tt := range inputs {
+		t.Run(string(tt.format), func(t *testing.T) {
+			tm := newTestManager()
+			original, _ := tm.AddTask("Original", "keep me", WithTags("keep"), WithDueDate(date(20)), WithPriority(PriorityHigh))
+			blocker, _ := tm.AddTask("Blocker", "")
+			tm.UpdateTask(blocker.ID, TaskPatch{Done: ptr(true)})
+			original, _ = tm.AddBlocker(original.ID, blocker.ID)
+
+			result, err := tm.Import(strings.NewReader(tt.input), tt.format, ImportMerge)
+			if err != nil || result.Updated != 1 || len(result.Errors) != 0 {
+				t.Fatalf("Expected 1 updated, got %+v %v", result, err)
+			}
+			got, _ := tm.GetTask(original.ID)
+			if got.Title != "Renamed" || !got.Done || got.CompletedAt == nil {
+				t.Errorf("Expected the row's title and status, got %+v", got)
+			}
+			if got.Description != "keep me" || !slices.Equal(got.Tags, []string{"keep"}) || got.Priority != PriorityHigh ||
+				got.DueDate == nil || !got.DueDate.Equal(date(20)) || !slices.Equal(got.BlockedBy, []int{blocker.ID}) {
+				t.Errorf("Expected the fields the row leaves out to be kept, got %+v", got)
+			}
+			if !got.CreatedAt.Equal(original.CreatedAt) || !got.UpdatedAt.After(original.UpdatedAt) {
+				t.Errorf("Expected created at %v kept and updated at moved on, got %v %v", original.CreatedAt, got.CreatedAt, got.UpdatedAt)
+			}
+		})
+	}
+}
+
+func TestImportReplaceCanBeUndone(t *testing.T) {
+	for _, factory := range storeFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			tm := NewTaskManagerWithStore(factory.new(t))
+			first, _ := tm.AddTask("First", "")
+			tm.AddTask("Second", "", WithParent(first.ID))
+			tm.AddTask("Third", "")
+
+			input := `[{"id": 2, "title": "Replacement"}, {"id": 9, "title": "New"}]`
+			result, err := tm.Import(strings.NewReader(input), FormatJSON, ImportReplace)
+			if err != nil || result.Created != 2 {
+				t.Fatalf("Expected 2 created, got %+v %v", result, err)
+			}
+			if got, _ := tm.GetTask(9); got.Title != "New" {
+				t.Errorf("Expected the row's ID to be kept, got %+v", got)
+			}
+			if next, _ := tm.AddTask("Next", ""); next.ID != 10 {
+				t.Errorf("Expected new IDs after the imported ones, got %d", next.ID)
+			}
+			tm.Undo()
+
+			// Replaced tasks wait in the trash, except the one whose ID the file took over
+			var trashed []string
+			for _, deleted := range tm.Trash() {
+				trashed = append(trashed, deleted.Task.Title)
+			}
+			slices.Sort(trashed)
+			if !slices.Equal(trashed, []string{"First", "Third"}) {
+				t.Errorf("Expected First and Third in the trash, got %v", trashed)
+			}
+
+			// One undo brings the old tasks back
+			if err := tm.Undo(); err != nil {
+				t.Fatalf("Undo failed: %v", err)
+			}
+			var titles []string
+			for _, task := range tm.ListTasks(nil) {
+				titles = append(titles, task.Title)
+			}
+			if !slices.Equal(titles, []string{"First", "Second", "Third"}) {
+				t.Errorf("Expected the original tasks back, got %v", titles)
+			}
+			if len(tm.Trash()) != 0 {
+				t.Errorf("Expected an empty trash after undo, got %+v", tm.Trash())
+			}
+
+			if err := tm.Redo(); err != nil {
+				t.Fatalf("Redo failed: %v", err)
+			}
+			if got, _ := tm.GetTask(2); got.Title != "Replacement" {
+				t.Errorf("Expected redo to import again, got %+v", got)
+			}
+		})
+	}
+}
+
+func TestImportRejectsUnreadableInput(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		format Format
+		mode   ImportMode
+		err    error
+	}{
+		{name: "unknown format", input: "", format: "xml", mode: ImportAppend, err: ErrUnknownFormat},
+		{name: "unknown mode", input: "[]", format: FormatJSON, mode: "upsert", err: ErrUnknownImportMode},
+		{name: "missing title column", input: "name,done\nx,true", format: FormatCSV, mode: ImportReplace, err: ErrMissingColumn},
+		{name: "not a JSON array", input: `{"title": "x"}`, format: FormatJSON, mode: ImportReplace},
+		{name: "not iCalendar", input: "BEGIN:VTODO\nEND:VTODO", format: FormatICS, mode: ImportReplace},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm := newTestManager()
+			tm.AddTask("Keep me", "")
+
+			_, err := tm.Import(strings.NewReader(tt.input), tt.format, tt.mode)
+			if err == nil || (tt.err != nil && !errors.Is(err, tt.err)) {
+				t.Errorf("Expected error %v, got %v", tt.err, err)
+			}
+			if got := len(tm.ListTasks(nil)); got != 1 {
+				t.Errorf("Expected the existing task to be kept, got %d tasks", got)
+			}
+		})
+	}
+}
+
+func TestImportICSFromOtherClients(t *testing.T) {
+	input := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"SUMMARY:Not a task",
+		"END:VEVENT",
+		"BEGIN:VTODO",
+		"UID:abc-123@example.com",
+		"SUMMARY:Pay rent\\, on time",
+		"DESCRIPTION:Line one\\nline ",
+		" two",
+		"PRIORITY:3",
+		"CATEGORIES:home,money\\,bills",
+		"DUE;VALUE=DATE:20250701",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"UID:def-456@example.com",
+		"SUMMARY:Transfer money",
+		"RELATED-TO:abc-123@example.com",
+		"DUE;TZID=UTC:20250630T180000",
+		"PRIORITY:6",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"SUMMARY:Broken",
+		"DUE:tomorrow",
+		"END:VTODO",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	tm := newTestManager()
+	result, err := tm.Import(strings.NewReader(input), FormatICS, ImportAppend)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if result.Created != 2 || len(result.Errors) != 1 || result.Errors[0].Row != 3 {
+		t.Fatalf("Expected 2 created and an error on row 3, got %+v", result)
+	}
+
+	rent, _ := tm.GetTask(1)
+	if rent.Title != "Pay rent, on time" || rent.Description != "Line one\nline two" || rent.Priority != PriorityHigh {
+		t.Errorf("Unexpected task: %+v", rent)
+	}
+	if !slices.Equal(rent.Tags, []string{"home", "money,bills"}) {
+		t.Errorf("Expected tags [home money,bills], got %v", rent.Tags)
+	}
+	if rent.DueDate == nil || !rent.DueDate.Equal(date(1).AddDate(0, 1, 0)) {
+		t.Errorf("Expected due July 1, got %v", rent.DueDate)
+	}
+
+	transfer, _ := tm.GetTask(2)
+	if transfer.ParentID != rent.ID || transfer.Priority != PriorityLow {
+		t.Errorf("Unexpected task: %+v", transfer)
+	}
+	if transfer.DueDate == nil || !transfer.DueDate.Equal(time.Date(2025, 6, 30, 18, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected due June 30 18:00, got %v", transfer.DueDate)
+	}
+}
+
+func TestExportICSFoldsLongLines(t *testing.T) {
+	tm := newTestManager()
+	tm.AddTask(strings.Repeat("ü", 60), "")
+
+	var buf bytes.Buffer
+	if err := tm.Export(&buf, FormatICS); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("Line longer than 75 octets: %q", line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("Line splits a UTF-8 sequence: %q", line)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package taskmanager
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// File log operations
+const (
+	opCreate = "create"
+	opUpdate = "update"
+	opDelete = "delete"
+	opTrash  = "trash"
+	opPurge  = "purge"
+)
+
+// fileRecord is one line of the append-only log
+type fileRecord struct {
+	Op   string     `json:"op"`
+	ID   int        `json:"id,omitempty"`
+	Task *Task      `json:"task,omitempty"`
+	At   *time.Time `json:"at,omitempty"`
+}
+
+// logFile is the part of *os.File the store uses
+type logFile interface {
+	io.ReadWriteSeeker
+	io.Closer
+	Truncate(size int64) error
+	Sync() error
+}
+
+// FileStore keeps tasks in an append-only JSON lines file. Every change is
+// appended as a record and the log is replayed into memory on open.
+type FileStore struct {
+	// mu keeps appends in the same order as the changes they describe
+	mu   sync.Mutex
+	file logFile
+	mem  *MemoryStore
+	// broken is set when a failed append could not be undone; the log may end
+	// in a torn record, so further appends are refused
+	broken error
+}
+
+// OpenFileStore opens or creates the log at path and replays it. A final line
+// cut short by a crash is discarded, while a complete one that only lacks its
+// newline is kept; any other malformed line is an error.
+func OpenFileStore(path string) (*FileStore, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &FileStore{file: file, mem: NewMemoryStore()}
+	if err := s.replay(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("replay %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// replay rebuilds the in-memory state from the log and leaves the file
+// positioned at the end of the last complete record
+func (s *FileStore) replay() error {
+	reader := bufio.NewReader(s.file)
+	var offset int64
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(bytes.TrimSpace(data)) == 0 {
+				break
+			}
+			var record fileRecord
+			if json.Unmarshal(data, &record) != nil {
+				// Torn write: drop the partial record so the next append starts cleanly
+				if err := s.file.Truncate(offset); err != nil {
+					return err
+				}
+				break
+			}
+			// A complete record, as in a hand-edited file; end it so the next append gets its own line
+			if err := s.apply(record); err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+			if _, err := s.file.Write([]byte("\n")); err != nil {
+				return err
+			}
+			offset += int64(len(data)) + 1
+			break
+		}
+		if err != nil {
+			return err
+		}
+		offset += int64(len(data))
+
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+		var record fileRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := s.apply(record); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+
+	_, err := s.file.Seek(offset, io.SeekStart)
+	return err
+}
+
+// apply updates the in-memory state with one log record
+func (s *FileStore) apply(record fileRecord) error {
+	s.mem.mu.Lock()
+	defer s.mem.mu.Unlock()
+
+	switch record.Op {
+	case opCreate, opUpdate:
+		if record.Task == nil {
+			return fmt.Errorf("%s record without task", record.Op)
+		}
+		s.mem.tasks[record.Task.ID] = record.Task.clone()
+		// Restore appends a create record for a task that may be in the trash
+		delete(s.mem.trash, record.Task.ID)
+		// IDs stay monotonic even when the newest tasks were deleted
+		if record.Task.ID >= s.mem.nextID {
+			s.mem.nextID = record.Task.ID + 1
+		}
+	case opDelete:
+		delete(s.mem.tasks, record.ID)
+	case opTrash:
+		task, ok := s.mem.tasks[record.ID]
+		if !ok || record.At == nil {
+			return fmt.Errorf("trash record for missing task %d", record.ID)
+		}
+		delete(s.mem.tasks, record.ID)
+		s.mem.trash[record.ID] = DeletedTask{Task: task, DeletedAt: *record.At}
+	case opPurge:
+		delete(s.mem.trash, record.ID)
+	default:
+		return fmt.Errorf("unknown op %q", record.Op)
+	}
+	return nil
+}
+
+// write appends a record and syncs it to disk before the change becomes visible.
+// A failed append is cut off again so the log never holds a record the caller
+// was told had failed.
+func (s *FileStore) write(record fileRecord) error {
+	if s.broken != nil {
+		return s.broken
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	offset, err := s.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := s.file.Write(data); err != nil {
+		return s.rollback(offset, fmt.Errorf("append task record: %w", err))
+	}
+	if err := s.file.Sync(); err != nil {
+		return s.rollback(offset, fmt.Errorf("sync task log: %w", err))
+	}
+	return s.apply(record)
+}
+
+// rollback truncates the log back to offset after a failed append and returns cause
+func (s *FileStore) rollback(offset int64, cause error) error {
+	if err := s.file.Truncate(offset); err != nil {
+		s.broken = fmt.Errorf("task log unusable after failed append: %w", errors.Join(cause, err))
+		return s.broken
+	}
+	if _, err := s.file.Seek(offset, io.SeekStart); err != nil {
+		s.broken = fmt.Errorf("task log unusable after failed append: %w", errors.Join(cause, err))
+		return s.broken
+	}
+	return cause
+}
+
+// Create appends a new task under the next ID
+func (s *FileStore) Create(task Task) (Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mem.mu.RLock()
+	task.ID = s.mem.nextID
+	s.mem.mu.RUnlock()
+
+	if err := s.write(fileRecord{Op: opCreate, Task: &task}); err != nil {
+		return Task{}, err
+	}
+	return task, nil
+}
+
+// Get returns the task with the given ID
+func (s *FileStore) Get(id int) (Task, error) {
+	return s.mem.Get(id)
+}
+
+// Update appends the new version of the task
+func (s *FileStore) Update(task Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.mem.Get(task.ID); err != nil {
+		return err
+	}
+	return s.write(fileRecord{Op: opUpdate, Task: &task})
+}
+
+// Delete appends a deletion record for the task
+func (s *FileStore) Delete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.mem.Get(id); err != nil {
+		return err
+	}
+	return s.write(fileRecord{Op: opDelete, ID: id})
+}
+
+// Trash appends a record moving the task to the trash
+func (s *FileStore) Trash(id int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.mem.Get(id); err != nil {
+		return err
+	}
+	return s.write(fileRecord{Op: opTrash, ID: id, At: &at})
+}
+
+// Trashed returns the tasks in the trash ordered by ID
+func (s *FileStore) Trashed() ([]DeletedTask, error) {
+	return s.mem.Trashed()
+}
+
+// Purge appends a record removing the task from the trash for good
+func (s *FileStore) Purge(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mem.mu.RLock()
+	_, ok := s.mem.trash[id]
+	s.mem.mu.RUnlock()
+	if !ok {
+		return ErrNotInTrash
+	}
+	return s.write(fileRecord{Op: opPurge, ID: id})
+}
+
+// Restore appends the task under its own ID
+func (s *FileStore) Restore(task Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.mem.Get(task.ID); err == nil {
+		return ErrTaskExists
+	}
+	return s.write(fileRecord{Op: opCreate, Task: &task})
+}
+
+// List returns every task ordered by ID
+func (s *FileStore) List() ([]Task, error) {
+	return s.mem.List()
+}
+
+// Close closes the underlying file
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
@@ -0,0 +1,417 @@
+package taskmanager
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// History errors
+var (
+	ErrNothingToUndo = errors.New("nothing to undo")
+	ErrNothingToRedo = errors.New("nothing to redo")
+	ErrNotInTrash    = errors.New("task not in trash")
+)
+
+// History defaults
+const (
+	// DefaultHistoryLimit is the number of commands kept for undo, and of versions kept per task
+	DefaultHistoryLimit = 100
+	// DefaultTrashRetention is how long deleted tasks can be restored
+	DefaultTrashRetention = 30 * 24 * time.Hour
+)
+
+// Action describes how a task changed
+type Action string
+
+// Recorded actions
+const (
+	ActionCreated  Action = "created"
+	ActionUpdated  Action = "updated"
+	ActionDeleted  Action = "deleted"
+	ActionRestored Action = "restored"
+)
+
+// TaskVersion is a task as it was after one change. For ActionDeleted it is
+// the task as it was when it was removed.
+type TaskVersion struct {
+	Action Action    `json:"action"`
+	Task   Task      `json:"task"`
+	At     time.Time `json:"at"`
+}
+
+// DeletedTask is a task in the trash. The trash is kept by the TaskStore, so
+// persistent stores keep deleted tasks across restarts.
+type DeletedTask struct {
+	Task      Task      `json:"task"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// ManagerOption configures a TaskManager
+type ManagerOption func(*TaskManager)
+
+// WithHistoryLimit sets how many commands can be undone and how many versions
+// History keeps per task. Values below 1 are ignored.
+func WithHistoryLimit(n int) ManagerOption {
+	return func(tm *TaskManager) {
+		if n > 0 {
+			tm.history.limit = n
+		}
+	}
+}
+
+// WithTrashRetention sets how long deleted tasks stay in the trash; zero or
+// less keeps them until the store is discarded
+func WithTrashRetention(d time.Duration) ManagerOption {
+	return func(tm *TaskManager) {
+		tm.history.retention = d
+	}
+}
+
+// change moves one task from before to after; a nil side means the task does
+// not exist. trash marks changes whose removed side belongs in the trash.
+type change struct {
+	before *Task
+	after  *Task
+	trash  bool
+}
+
+// command is the set of changes made by one mutation, undone as a unit
+type command []change
+
+// touches reports whether any change of c involves a task in ids
+func (c command) touches(ids map[int]bool) bool {
+	for _, ch := range c {
+		if (ch.before != nil && ids[ch.before.ID]) || (ch.after != nil && ids[ch.after.ID]) {
+			return true
+		}
+	}
+	return false
+}
+
+// inverse returns the changes that take the store back from after c to before
+// it: the changes in reverse order, each with its sides swapped
+func (c command) inverse() command {
+	result := make(command, len(c))
+	for i, ch := range c {
+		result[len(c)-1-i] = change{before: ch.after, after: ch.before, trash: ch.trash}
+	}
+	return result
+}
+
+// history holds the undo and redo stacks and task versions. It lives in
+// memory and is guarded by TaskManager.mu.
+type history struct {
+	limit     int
+	retention time.Duration
+	undo      []command
+	redo      []command
+	versions  map[int][]TaskVersion
+}
+
+func newHistory() history {
+	return history{
+		limit:     DefaultHistoryLimit,
+		retention: DefaultTrashRetention,
+		versions:  make(map[int][]TaskVersion),
+	}
+}
+
+// Undo reverts the most recent AddTask, UpdateTask, DeleteTask or RestoreTask
+// call that has not been undone yet. Undoing a deletion takes the task back
+// out of the trash. If the store fails, the command is left as it was and can
+// be undone again.
+func (tm *TaskManager) Undo() error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	h := &tm.history
+	if len(h.undo) == 0 {
+		return ErrNothingToUndo
+	}
+	cmd := h.undo[len(h.undo)-1]
+
+	now := tm.now()
+	if err := tm.replay(cmd.inverse(), now); err != nil {
+		return err
+	}
+	h.undo = h.undo[:len(h.undo)-1]
+	h.redo = append(h.redo, cmd)
+	return nil
+}
+
+// Redo repeats the most recently undone command. Any other change clears the
+// commands that could be redone.
+func (tm *TaskManager) Redo() error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	h := &tm.history
+	if len(h.redo) == 0 {
+		return ErrNothingToRedo
+	}
+	cmd := h.redo[len(h.redo)-1]
+
+	now := tm.now()
+	if err := tm.replay(cmd, now); err != nil {
+		return err
+	}
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = append(h.undo, cmd)
+	return nil
+}
+
+// History returns the recorded versions of a task, oldest first. Only changes
+// made through this manager are recorded, and at most the history limit of
+// them. Returns ErrTaskNotFound if the task is unknown.
+func (tm *TaskManager) History(id int) ([]TaskVersion, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	versions := tm.history.versions[id]
+	if len(versions) == 0 {
+		if _, err := tm.store.Get(id); err != nil {
+			if _, inTrash, trashErr := tm.trashed(id); trashErr != nil || !inTrash {
+				return nil, err
+			}
+		}
+	}
+
+	result := make([]TaskVersion, len(versions))
+	for i, version := range versions {
+		version.Task = version.Task.clone()
+		result[i] = version
+	}
+	return result, nil
+}
+
+// Trash returns the deleted tasks that can still be restored, most recently
+// deleted first, or an empty slice if the store cannot be read
+func (tm *TaskManager) Trash() []DeletedTask {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	tm.purgeExpired(tm.now())
+	result, err := tm.store.Trashed()
+	if err != nil {
+		return []DeletedTask{}
+	}
+	slices.SortFunc(result, func(a, b DeletedTask) int {
+		if c := b.DeletedAt.Compare(a.DeletedAt); c != 0 {
+			return c
+		}
+		return a.Task.ID - b.Task.ID
+	})
+	return result
+}
+
+// RestoreTask takes a task out of the trash under its old ID. Returns
+// ErrNotInTrash if it was never deleted or has been purged, and
+// ErrParentNotFound if its parent no longer exists.
+func (tm *TaskManager) RestoreTask(id int) (Task, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	now := tm.now()
+	tm.purgeExpired(now)
+	deleted, ok, err := tm.trashed(id)
+	if err != nil {
+		return Task{}, err
+	}
+	if !ok {
+		return Task{}, ErrNotInTrash
+	}
+	task := deleted.Task
+	// Blockers deleted in the meantime no longer hold the task back
+	task.BlockedBy = slices.DeleteFunc(task.BlockedBy, func(blocker int) bool {
+		_, err := tm.store.Get(blocker)
+		return errors.Is(err, ErrTaskNotFound)
+	})
+	if len(task.BlockedBy) == 0 {
+		task.BlockedBy = nil
+	}
+	if task.ParentID != 0 {
+		if err := tm.checkParent(task); err != nil {
+			return Task{}, err
+		}
+	}
+
+	if err := tm.store.Restore(task); err != nil {
+		return Task{}, err
+	}
+	tm.record(command{{after: &task, trash: true}}, now)
+	return task.clone(), nil
+}
+
+// PurgeTrash permanently removes tasks deleted longer ago than the retention
+// period and returns how many were removed. Expired tasks are also purged
+// whenever the trash is used; tasks the store fails to purge are retried then.
+func (tm *TaskManager) PurgeTrash() int {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	return tm.purgeExpired(tm.now())
+}
+
+// purgeExpired drops expired trash entries together with their versions and
+// every command that would need them back
+func (tm *TaskManager) purgeExpired(now time.Time) int {
+	h := &tm.history
+	if h.retention <= 0 {
+		return 0
+	}
+
+	trashed, err := tm.store.Trashed()
+	if err != nil {
+		return 0
+	}
+	purged := map[int]bool{}
+	for _, deleted := range trashed {
+		id := deleted.Task.ID
+		if now.Sub(deleted.DeletedAt) >= h.retention && tm.store.Purge(id) == nil {
+			purged[id] = true
+			delete(h.versions, id)
+		}
+	}
+	if len(purged) > 0 {
+		h.undo = dropThrough(h.undo, purged)
+		h.redo = dropThrough(h.redo, purged)
+	}
+	return len(purged)
+}
+
+// trashed looks a task up in the trash
+func (tm *TaskManager) trashed(id int) (DeletedTask, bool, error) {
+	trashed, err := tm.store.Trashed()
+	if err != nil {
+		return DeletedTask{}, false, err
+	}
+	i, found := slices.BinarySearchFunc(trashed, id, func(deleted DeletedTask, id int) int {
+		return deleted.Task.ID - id
+	})
+	if !found {
+		return DeletedTask{}, false, nil
+	}
+	return trashed[i], true, nil
+}
+
+// dropThrough removes the newest command in stack that touches ids along with
+// every older one, since those can only be replayed after it
+func dropThrough(stack []command, ids map[int]bool) []command {
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i].touches(ids) {
+			return slices.Delete(stack, 0, i+1)
+		}
+	}
+	return stack
+}
+
+// replay makes the changes of cmd while undoing or redoing, adding a version
+// and publishing an event for each once all of them are stored
+func (tm *TaskManager) replay(cmd command, now time.Time) error {
+	if err := tm.applyAll(cmd, now); err != nil {
+		return err
+	}
+	for _, ch := range cmd {
+		switch {
+		case ch.before == nil:
+			tm.addVersion(ActionRestored, *ch.after, now)
+		case ch.after == nil:
+			tm.addVersion(ActionDeleted, *ch.before, now)
+		default:
+			tm.addVersion(ActionUpdated, *ch.after, now)
+		}
+		tm.publish(ch.before, ch.after, now)
+	}
+	return nil
+}
+
+// applyAll makes the changes of cmd in the store in order. If one fails, the
+// changes already made are reverted so the store is left as it was.
+func (tm *TaskManager) applyAll(cmd command, now time.Time) error {
+	for i, ch := range cmd {
+		if err := tm.apply(ch, now); err != nil {
+			return tm.revert(cmd[:i], err, now)
+		}
+	}
+	return nil
+}
+
+// revert undoes the changes of applied after cause stopped a command partway.
+// It returns cause, joined with the first error met while reverting.
+func (tm *TaskManager) revert(applied command, cause error, now time.Time) error {
+	for _, ch := range applied.inverse() {
+		if err := tm.apply(ch, now); err != nil {
+			return errors.Join(cause, fmt.Errorf("revert task %d: %w", changedID(ch), err))
+		}
+	}
+	return cause
+}
+
+// apply makes one change in the store
+func (tm *TaskManager) apply(ch change, now time.Time) error {
+	switch {
+	case ch.before == nil:
+		return tm.store.Restore(*ch.after)
+	case ch.after == nil && ch.trash:
+		return tm.store.Trash(ch.before.ID, now)
+	case ch.after == nil:
+		return tm.store.Delete(ch.before.ID)
+	default:
+		return tm.store.Update(*ch.after)
+	}
+}
+
+// changedID returns the ID of the task a change moves
+func changedID(ch change) int {
+	if ch.after != nil {
+		return ch.after.ID
+	}
+	return ch.before.ID
+}
+
+// record pushes a new command onto the undo stack, clears the redo stack and
+// adds a version for each change
+func (tm *TaskManager) record(cmd command, now time.Time) {
+	h := &tm.history
+	for i, ch := range cmd {
+		// Keep private copies; callers go on to return the same tasks
+		if ch.before != nil {
+			before := ch.before.clone()
+			cmd[i].before = &before
+		}
+		if ch.after != nil {
+			after := ch.after.clone()
+			cmd[i].after = &after
+		}
+
+		switch {
+		case ch.before == nil && ch.trash:
+			tm.addVersion(ActionRestored, *ch.after, now)
+		case ch.before == nil:
+			tm.addVersion(ActionCreated, *ch.after, now)
+		case ch.after == nil:
+			tm.addVersion(ActionDeleted, *ch.before, now)
+		default:
+			tm.addVersion(ActionUpdated, *ch.after, now)
+		}
+		tm.publish(cmd[i].before, cmd[i].after, now)
+	}
+
+	h.undo = append(h.undo, cmd)
+	if len(h.undo) > h.limit {
+		h.undo = slices.Delete(h.undo, 0, len(h.undo)-h.limit)
+	}
+	h.redo = nil
+}
+
+// addVersion appends a version of task, dropping the oldest beyond the limit
+func (tm *TaskManager) addVersion(action Action, task Task, now time.Time) {
+	h := &tm.history
+	versions := append(h.versions[task.ID], TaskVersion{Action: action, Task: task.clone(), At: now})
+	if len(versions) > h.limit {
+		versions = slices.Delete(versions, 0, len(versions)-h.limit)
+	}
+	h.versions[task.ID] = versions
+}
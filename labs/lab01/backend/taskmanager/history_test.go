@@ -0,0 +1,335 @@
+package taskmanager
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func titles(tm *TaskManager) []string {
+	result := []string{}
+	for _, task := range tm.ListTasks(nil) {
+		result = append(result, task.Title)
+	}
+	return result
+}
+
+func TestUndoRedo(t *testing.T) {
+	tm := newTestManager()
+	first, _ := tm.AddTask("First", "")
+	tm.AddTask("Second", "")
+	tm.UpdateTask(first.ID, TaskPatch{Title: ptr("First, renamed")})
+	tm.DeleteTask(2)
+
+	steps := []struct {
+		undo     bool
+		expected []string
+	}{
+		{undo: true, expected: []string{"First, renamed", "Second"}},
+		{undo: true, expected: []string{"First", "Second"}},
+		{undo: true, expected: []string{"First"}},
+		{undo: false, expected: []string{"First", "Second"}},
+		{undo: false, expected: []string{"First, renamed", "Second"}},
+		{undo: false, expected: []string{"First, renamed"}},
+	}
+	for i, step := range steps {
+		op := tm.Redo
+		if step.undo {
+			op = tm.Undo
+		}
+		if err := op(); err != nil {
+			t.Fatalf("Step %d failed: %v", i, err)
+		}
+		if got := titles(tm); !slices.Equal(got, step.expected) {
+			t.Errorf("Step %d: expected %v, got %v", i, step.expected, got)
+		}
+	}
+	if err := tm.Redo(); err != ErrNothingToRedo {
+		t.Errorf("Expected ErrNothingToRedo, got %v", err)
+	}
+
+	// Undone tasks come back under their old ID, and a new change discards the redo stack
+	tm.Undo()
+	if task, err := tm.GetTask(2); err != nil || task.Title != "Second" {
+		t.Errorf("Expected task 2 to be restored, got %+v %v", task, err)
+	}
+	tm.AddTask("Third", "")
+	if err := tm.Redo(); err != ErrNothingToRedo {
+		t.Errorf("Expected ErrNothingToRedo after a new change, got %v", err)
+	}
+	if third, _ := tm.GetTask(3); third.Title != "Third" {
+		t.Errorf("Expected a fresh ID for a new task, got %+v", third)
+	}
+
+	for tm.Undo() == nil {
+	}
+	if got := titles(tm); len(got) != 0 {
+		t.Errorf("Expected undoing everything to leave no tasks, got %v", got)
+	}
+	if err := tm.Undo(); err != ErrNothingToUndo {
+		t.Errorf("Expected ErrNothingToUndo, got %v", err)
+	}
+}
+
+var errWriteFailed = errors.New("write failed")
+
+// failingStore fails its failAt-th write; zero never fails
+type failingStore struct {
+	TaskStore
+	writes int
+	failAt int
+}
+
+func (s *failingStore) write() error {
+	s.writes++
+	if s.writes == s.failAt {
+		return errWriteFailed
+	}
+	return nil
+}
+
+// failNext makes the nth write from now fail
+func (s *failingStore) failNext(n int) {
+	s.failAt = s.writes + n
+}
+
+func (s *failingStore) Create(task Task) (Task, error) {
+	if err := s.write(); err != nil {
+		return Task{}, err
+	}
+	return s.TaskStore.Create(task)
+}
+
+func (s *failingStore) Update(task Task) error {
+	if err := s.write(); err != nil {
+		return err
+	}
+	return s.TaskStore.Update(task)
+}
+
+func (s *failingStore) Delete(id int) error {
+	if err := s.write(); err != nil {
+		return err
+	}
+	return s.TaskStore.Delete(id)
+}
+
+func (s *failingStore) Trash(id int, at time.Time) error {
+	if err := s.write(); err != nil {
+		return err
+	}
+	return s.TaskStore.Trash(id, at)
+}
+
+func (s *failingStore) Restore(task Task) error {
+	if err := s.write(); err != nil {
+		return err
+	}
+	return s.TaskStore.Restore(task)
+}
+
+// newFailingManager returns a manager whose store can be told to fail a write
+func newFailingManager() (*TaskManager, *failingStore) {
+	tm := newTestManager()
+	store := &failingStore{TaskStore: tm.store}
+	tm.store = store
+	return tm, store
+}
+
+func TestUndoRedoRollBackFailedCommands(t *testing.T) {
+	tm, store := newFailingManager()
+	tm.AddTask("Design", "")
+	tm.AddTask("Build", "", WithBlockers(1))
+	tm.AddTask("Docs", "", WithBlockers(1))
+	if _, err := tm.DeleteTask(1); err != nil {
+		t.Fatalf("Failed to delete task: %v", err)
+	}
+
+	// state reports whether task 1 exists and which tasks still wait for it
+	state := func() (bool, []int) {
+		_, err := tm.GetTask(1)
+		waiting := []int{}
+		for _, id := range []int{2, 3} {
+			if task, _ := tm.GetTask(id); slices.Contains(task.BlockedBy, 1) {
+				waiting = append(waiting, id)
+			}
+		}
+		return err == nil, waiting
+	}
+
+	// Undo restores 1, then points 3 and 2 back at it; the second write fails
+	store.failNext(2)
+	if err := tm.Undo(); !errors.Is(err, errWriteFailed) {
+		t.Fatalf("Expected the undo to fail, got %v", err)
+	}
+	if exists, waiting := state(); exists || len(waiting) != 0 {
+		t.Errorf("Expected a failed undo to change nothing, got task 1 %v and waiting %v", exists, waiting)
+	}
+	if trash := tm.Trash(); len(trash) != 1 || trash[0].Task.ID != 1 {
+		t.Errorf("Expected task 1 back in the trash, got %v", trash)
+	}
+	if err := tm.Undo(); err != nil {
+		t.Fatalf("Expected the undo to be retried, got %v", err)
+	}
+	if exists, waiting := state(); !exists || !slices.Equal(waiting, []int{2, 3}) {
+		t.Errorf("Expected task 1 back with 2 and 3 waiting, got %v %v", exists, waiting)
+	}
+
+	// Redo unlinks 2 and 3, then trashes 1; the trash fails
+	store.failNext(3)
+	if err := tm.Redo(); !errors.Is(err, errWriteFailed) {
+		t.Fatalf("Expected the redo to fail, got %v", err)
+	}
+	if exists, waiting := state(); !exists || !slices.Equal(waiting, []int{2, 3}) {
+		t.Errorf("Expected a failed redo to change nothing, got %v %v", exists, waiting)
+	}
+	if err := tm.Redo(); err != nil {
+		t.Fatalf("Expected the redo to be retried, got %v", err)
+	}
+	if exists, waiting := state(); exists || len(waiting) != 0 {
+		t.Errorf("Expected task 1 deleted again, got %v %v", exists, waiting)
+	}
+}
+
+func TestUndoCompletingRecurringTask(t *testing.T) {
+	tm := newTestManager()
+	rule, _ := ParseRRule("FREQ=DAILY")
+	task, _ := tm.AddTask("Stand-up", "", WithDueDate(at(2025, 6, 2)), WithRecurrence(rule))
+	tm.UpdateTask(task.ID, TaskPatch{Done: ptr(true)})
+
+	if err := tm.Undo(); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	tasks := tm.ListTasks(nil)
+	if len(tasks) != 1 || tasks[0].Done || tasks[0].Recurrence == nil || tasks[0].CompletedAt != nil {
+		t.Errorf("Expected only the open recurring task, got %+v", tasks)
+	}
+}
+
+func TestCompletingRecurringTaskIsAtomic(t *testing.T) {
+	rule, _ := ParseRRule("FREQ=DAILY")
+	// The next occurrence is created by the first write and the task saved by the second
+	for _, failAt := range []int{1, 2} {
+		tm, store := newFailingManager()
+		task, _ := tm.AddTask("Stand-up", "", WithDueDate(at(2025, 6, 2)), WithRecurrence(rule))
+
+		store.failNext(failAt)
+		if _, err := tm.UpdateTask(task.ID, TaskPatch{Done: ptr(true)}); !errors.Is(err, errWriteFailed) {
+			t.Fatalf("Expected write %d to fail, got %v", failAt, err)
+		}
+		tasks := tm.ListTasks(nil)
+		if len(tasks) != 1 || tasks[0].Done || tasks[0].Recurrence == nil {
+			t.Errorf("Expected only the open recurring task after write %d failed, got %+v", failAt, tasks)
+		}
+		if err := tm.Undo(); err != nil {
+			t.Errorf("Expected the task's creation to be the last command, got %v", err)
+		}
+	}
+}
+
+func TestHistoryLimit(t *testing.T) {
+	tm := NewTaskManager(WithHistoryLimit(2))
+	task, _ := tm.AddTask("Task", "")
+	for _, title := range []string{"A", "B", "C"} {
+		tm.UpdateTask(task.ID, TaskPatch{Title: ptr(title)})
+	}
+
+	tm.Undo()
+	tm.Undo()
+	if err := tm.Undo(); err != ErrNothingToUndo {
+		t.Errorf("Expected ErrNothingToUndo, got %v", err)
+	}
+	if got, _ := tm.GetTask(task.ID); got.Title != "A" {
+		t.Errorf("Expected title A, got %q", got.Title)
+	}
+
+	versions, _ := tm.History(task.ID)
+	if len(versions) != 2 {
+		t.Errorf("Expected 2 versions, got %d", len(versions))
+	}
+}
+
+func TestHistory(t *testing.T) {
+	tm := newTestManager()
+	task, _ := tm.AddTask("Draft", "")
+	tm.UpdateTask(task.ID, TaskPatch{Done: ptr(true)})
+	tm.DeleteTask(task.ID)
+	tm.Undo()
+
+	versions, err := tm.History(task.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []Action{ActionCreated, ActionUpdated, ActionDeleted, ActionRestored}
+	if len(versions) != len(expected) {
+		t.Fatalf("Expected %d versions, got %+v", len(expected), versions)
+	}
+	for i, version := range versions {
+		if version.Action != expected[i] {
+			t.Errorf("Version %d: expected %s, got %s", i, expected[i], version.Action)
+		}
+		if i > 0 && !version.At.After(versions[i-1].At) {
+			t.Errorf("Version %d: expected a later timestamp than %v, got %v", i, versions[i-1].At, version.At)
+		}
+	}
+	if versions[0].Task.Done || !versions[1].Task.Done {
+		t.Errorf("Expected versions to capture the done flag, got %+v", versions)
+	}
+
+	if _, err := tm.History(99); err != ErrTaskNotFound {
+		t.Errorf("Expected ErrTaskNotFound, got %v", err)
+	}
+}
+
+func TestTrash(t *testing.T) {
+	tm := newTestManager()
+	clock := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	tm.now = func() time.Time { return clock }
+
+	parent, _ := tm.AddTask("Parent", "")
+	child, _ := tm.AddTask("Child", "", WithParent(parent.ID))
+	tm.DeleteTask(child.ID)
+	clock = clock.Add(time.Hour)
+	tm.DeleteTask(parent.ID)
+
+	trash := tm.Trash()
+	if len(trash) != 2 || trash[0].Task.ID != parent.ID || trash[1].Task.ID != child.ID {
+		t.Fatalf("Expected parent then child in the trash, got %+v", trash)
+	}
+	if _, err := tm.RestoreTask(child.ID); !errors.Is(err, ErrParentNotFound) {
+		t.Errorf("Expected ErrParentNotFound, got %v", err)
+	}
+
+	restored, err := tm.RestoreTask(parent.ID)
+	if err != nil || restored.ID != parent.ID {
+		t.Fatalf("Expected task %d back, got %+v %v", parent.ID, restored, err)
+	}
+	if _, err := tm.RestoreTask(parent.ID); err != ErrNotInTrash {
+		t.Errorf("Expected ErrNotInTrash, got %v", err)
+	}
+
+	// Restoring is a command like any other
+	tm.Undo()
+	if _, err := tm.GetTask(parent.ID); err != ErrTaskNotFound || len(tm.Trash()) != 2 {
+		t.Errorf("Expected undo to put the parent back in the trash, got %v", err)
+	}
+
+	// The child expires first; purging it also drops the commands that need it
+	clock = clock.Add(DefaultTrashRetention - time.Minute)
+	if n := tm.PurgeTrash(); n != 1 {
+		t.Errorf("Expected 1 task purged, got %d", n)
+	}
+	if _, err := tm.RestoreTask(child.ID); err != ErrNotInTrash {
+		t.Errorf("Expected ErrNotInTrash for a purged task, got %v", err)
+	}
+	if _, err := tm.History(child.ID); err != ErrTaskNotFound {
+		t.Errorf("Expected the purged task's history to be gone, got %v", err)
+	}
+	if err := tm.Undo(); err != nil {
+		t.Errorf("Expected the parent's deletion to stay undoable, got %v", err)
+	}
+	if err := tm.Undo(); err != ErrNothingToUndo {
+		t.Errorf("Expected older commands to be dropped, got %v", err)
+	}
+}
@@ -0,0 +1,392 @@
+package taskmanager
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// iCalendar (RFC 5545) constants
+const (
+	icsProdID    = "-//sum25-go-flutter-course//taskmanager//EN"
+	icsUIDSuffix = "@taskmanager"
+	icsDateTime  = "20060102T150405Z"
+	icsDate      = "20060102"
+	icsLineLimit = 75
+)
+
+// exportICS writes tasks as VTODO components of one VCALENDAR
+func exportICS(w io.Writer, tasks []Task, stamp time.Time) error {
+	out := &icsWriter{w: bufio.NewWriter(w)}
+	out.line("BEGIN", "VCALENDAR")
+	out.line("VERSION", "2.0")
+	out.line("PRODID", icsProdID)
+
+	for _, task := range tasks {
+		out.line("BEGIN", "VTODO")
+		out.line("UID", icsUID(task.ID))
+		out.line("DTSTAMP", stamp.UTC().Format(icsDateTime))
+		out.line("CREATED", task.CreatedAt.UTC().Format(icsDateTime))
+		out.line("LAST-MODIFIED", task.UpdatedAt.UTC().Format(icsDateTime))
+		out.line("SUMMARY", icsEscape(task.Title))
+		if task.Description != "" {
+			out.line("DESCRIPTION", icsEscape(task.Description))
+		}
+		if task.Done {
+			out.line("STATUS", "COMPLETED")
+			if task.CompletedAt != nil {
+				out.line("COMPLETED", task.CompletedAt.UTC().Format(icsDateTime))
+			}
+		} else {
+			out.line("STATUS", "NEEDS-ACTION")
+		}
+		if task.Priority != PriorityNone {
+			out.line("PRIORITY", strconv.Itoa(icsPriority(task.Priority)))
+		}
+		if len(task.Tags) > 0 {
+			tags := make([]string, len(task.Tags))
+			for i, tag := range task.Tags {
+				tags[i] = icsEscape(tag)
+			}
+			out.line("CATEGORIES", strings.Join(tags, ","))
+		}
+		if task.DueDate != nil {
+			out.line("DUE", task.DueDate.UTC().Format(icsDateTime))
+		}
+		if task.Recurrence != nil {
+			out.line("RRULE", task.Recurrence.String())
+		}
+		if task.ParentID != 0 {
+			out.line("RELATED-TO", icsUID(task.ParentID))
+		}
+		for _, blocker := range task.BlockedBy {
+			out.line("RELATED-TO;RELTYPE=DEPENDS-ON", icsUID(blocker))
+		}
+		out.line("END", "VTODO")
+	}
+
+	out.line("END", "VCALENDAR")
+	if out.err != nil {
+		return out.err
+	}
+	return out.w.Flush()
+}
+
+// icsWriter writes content lines with CRLF endings, folded at 75 octets
+type icsWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (o *icsWriter) line(name, value string) {
+	if o.err != nil {
+		return
+	}
+	line := name + ":" + value
+	// Continuation lines start with a space, which counts towards the limit
+	for limit := icsLineLimit; len(line) > limit; limit = icsLineLimit - 1 {
+		// Fold between UTF-8 sequences, never inside one
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		_, o.err = o.w.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+	}
+	if o.err == nil {
+		_, o.err = o.w.WriteString(line + "\r\n")
+	}
+}
+
+// icsProperty is one parsed content line
+type icsProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// decodeICS reads the VTODO components of an iCalendar file. Other components
+// are skipped. The UID links RELATED-TO parents; UIDs written by Export also
+// carry the task ID used by ImportMerge.
+func decodeICS(r io.Reader) ([]importRow, []RowError, error) {
+	lines, err := icsUnfold(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read iCalendar: %w", err)
+	}
+	if len(lines) == 0 || !strings.EqualFold(lines[0], "BEGIN:VCALENDAR") {
+		return nil, nil, fmt.Errorf("read iCalendar: missing BEGIN:VCALENDAR")
+	}
+
+	var (
+		rows    []importRow
+		rowErrs []RowError
+		todo    []icsProperty
+		inTodo  bool
+		count   int
+	)
+	for _, line := range lines {
+		prop, err := icsParseLine(line)
+		if err != nil {
+			if inTodo {
+				// Keep the error for the component; it is reported once the component ends
+				todo = append(todo, icsProperty{name: "X-INVALID", value: err.Error()})
+			}
+			continue
+		}
+
+		switch {
+		case prop.name == "BEGIN" && strings.EqualFold(prop.value, "VTODO"):
+			inTodo, todo = true, nil
+			count++
+		case prop.name == "END" && strings.EqualFold(prop.value, "VTODO") && inTodo:
+			inTodo = false
+			row, err := icsTask(todo)
+			if err != nil {
+				rowErrs = append(rowErrs, RowError{Row: count, Err: err})
+				continue
+			}
+			row.row = count
+			rows = append(rows, row)
+		case inTodo:
+			todo = append(todo, prop)
+		}
+	}
+	if inTodo {
+		rowErrs = append(rowErrs, RowError{Row: count, Err: fmt.Errorf("missing END:VTODO")})
+	}
+	return rows, rowErrs, nil
+}
+
+// icsTask converts the properties of one VTODO
+func icsTask(props []icsProperty) (importRow, error) {
+	row := importRow{fields: map[string]bool{}}
+	task := &row.task
+	for _, prop := range props {
+		if field, ok := icsFields[prop.name]; ok {
+			row.fields[field] = true
+		}
+
+		var err error
+		switch prop.name {
+		case "X-INVALID":
+			err = fmt.Errorf("%s", prop.value)
+		case "UID":
+			row.ref = prop.value
+			task.ID, _ = icsTaskID(prop.value)
+		case "SUMMARY":
+			task.Title = icsUnescape(prop.value)
+		case "DESCRIPTION":
+			task.Description = icsUnescape(prop.value)
+		case "STATUS":
+			task.Done = strings.EqualFold(prop.value, "COMPLETED")
+		case "PRIORITY":
+			task.Priority, err = priorityFromICS(prop.value)
+		case "CATEGORIES":
+			for _, tag := range icsSplit(prop.value) {
+				task.Tags = append(task.Tags, icsUnescape(tag))
+			}
+		case "DUE":
+			var due time.Time
+			if due, err = icsTime(prop); err == nil {
+				task.DueDate = &due
+			}
+		case "COMPLETED":
+			var completed time.Time
+			if completed, err = icsTime(prop); err == nil {
+				task.CompletedAt = &completed
+			}
+		case "CREATED":
+			task.CreatedAt, err = icsTime(prop)
+		case "LAST-MODIFIED":
+			task.UpdatedAt, err = icsTime(prop)
+		case "RRULE":
+			var rule RRule
+			if rule, err = ParseRRule(prop.value); err == nil {
+				task.Recurrence = &rule
+			}
+		case "RELATED-TO":
+			switch reltype := strings.ToUpper(prop.params["RELTYPE"]); reltype {
+			case "", "PARENT":
+				row.parentRef = prop.value
+				row.fields["parent_id"] = true
+			case "DEPENDS-ON":
+				row.blockerRefs = append(row.blockerRefs, prop.value)
+				row.fields["blocked_by"] = true
+			}
+		}
+		if err != nil {
+			return importRow{}, fmt.Errorf("%s: %w", prop.name, err)
+		}
+	}
+	if task.CompletedAt != nil {
+		task.Done = true
+		row.fields["done"] = true
+	}
+	return row, nil
+}
+
+// icsFields maps VTODO properties to the csvColumns they set
+var icsFields = map[string]string{
+	"SUMMARY":       "title",
+	"DESCRIPTION":   "description",
+	"STATUS":        "done",
+	"PRIORITY":      "priority",
+	"CATEGORIES":    "tags",
+	"DUE":           "due_date",
+	"COMPLETED":     "completed_at",
+	"CREATED":       "created_at",
+	"LAST-MODIFIED": "updated_at",
+	"RRULE":         "recurrence",
+}
+
+// icsUnfold joins folded lines and drops blank ones
+func icsUnfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// icsParseLine splits NAME;PARAM=VALUE:value
+func icsParseLine(line string) (icsProperty, error) {
+	// The value starts at the first colon outside a quoted parameter value
+	colon, quoted := -1, false
+	for i, c := range line {
+		if c == '"' {
+			quoted = !quoted
+		} else if c == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon <= 0 {
+		return icsProperty{}, fmt.Errorf("malformed line %q", line)
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	prop := icsProperty{name: strings.ToUpper(parts[0]), params: map[string]string{}, value: line[colon+1:]}
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+	return prop, nil
+}
+
+// icsTime parses DATE, UTC DATE-TIME, floating DATE-TIME (read as UTC) and DATE-TIME with TZID
+func icsTime(prop icsProperty) (time.Time, error) {
+	if prop.params["VALUE"] == "DATE" || len(prop.value) == len(icsDate) {
+		return time.Parse(icsDate, prop.value)
+	}
+	if strings.HasSuffix(prop.value, "Z") {
+		return time.Parse(icsDateTime, prop.value)
+	}
+
+	loc := time.UTC
+	if tzid := prop.params["TZID"]; tzid != "" {
+		var err error
+		if loc, err = time.LoadLocation(tzid); err != nil {
+			return time.Time{}, fmt.Errorf("unknown TZID %q", tzid)
+		}
+	}
+	return time.ParseInLocation("20060102T150405", prop.value, loc)
+}
+
+// icsPriority maps to the iCalendar 1 (highest) to 9 (lowest) scale
+func icsPriority(p Priority) int {
+	switch p {
+	case PriorityHigh:
+		return 1
+	case PriorityMedium:
+		return 5
+	case PriorityLow:
+		return 9
+	}
+	return 0
+}
+
+// priorityFromICS maps 1-4 to high, 5 to medium, 6-9 to low and 0 to none, as RFC 5545 suggests
+func priorityFromICS(value string) (Priority, error) {
+	n, err := strconv.Atoi(value)
+	switch {
+	case err != nil || n < 0 || n > 9:
+		return PriorityNone, fmt.Errorf("%w: %q", ErrInvalidPriority, value)
+	case n == 0:
+		return PriorityNone, nil
+	case n <= 4:
+		return PriorityHigh, nil
+	case n == 5:
+		return PriorityMedium, nil
+	}
+	return PriorityLow, nil
+}
+
+func icsUID(id int) string {
+	return "task-" + strconv.Itoa(id) + icsUIDSuffix
+}
+
+// icsTaskID extracts the task ID from a UID written by icsUID
+func icsTaskID(uid string) (int, bool) {
+	rest, ok := strings.CutPrefix(uid, "task-")
+	if !ok {
+		return 0, false
+	}
+	rest, ok = strings.CutSuffix(rest, icsUIDSuffix)
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.Atoi(rest)
+	return id, err == nil && id > 0
+}
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`, "\r", "")
+
+func icsEscape(s string) string {
+	return icsEscaper.Replace(s)
+}
+
+func icsUnescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			if s[i] == 'n' || s[i] == 'N' {
+				b.WriteByte('\n')
+			} else {
+				b.WriteByte(s[i])
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// icsSplit splits a list value on commas that are not escaped
+func icsSplit(s string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case ',':
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -0,0 +1,248 @@
+package taskmanager
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Query errors
+var (
+	ErrInvalidSortField = errors.New("invalid sort field")
+	ErrInvalidCursor    = errors.New("invalid cursor")
+)
+
+// SortField names the task field a query is ordered by
+type SortField string
+
+// Sortable fields
+const (
+	SortByID          SortField = "id"
+	SortByTitle       SortField = "title"
+	SortByDone        SortField = "done"
+	SortByPriority    SortField = "priority"
+	SortByDueDate     SortField = "due_date"
+	SortByCreatedAt   SortField = "created_at"
+	SortByUpdatedAt   SortField = "updated_at"
+	SortByCompletedAt SortField = "completed_at"
+)
+
+// Valid reports whether f is a sortable field; the empty value sorts by ID
+func (f SortField) Valid() bool {
+	switch f {
+	case "", SortByID, SortByTitle, SortByDone, SortByPriority, SortByDueDate,
+		SortByCreatedAt, SortByUpdatedAt, SortByCompletedAt:
+		return true
+	}
+	return false
+}
+
+// ListOptions selects, orders and pages tasks. Zero values disable a filter.
+type ListOptions struct {
+	// Done keeps only done or only open tasks
+	Done *bool
+	// Text keeps tasks whose title or description contains it, ignoring case
+	Text string
+	// Tags keeps tasks that carry every listed tag, ignoring case
+	Tags []string
+	// Priorities keeps tasks with any of the listed priorities
+	Priorities []Priority
+	// DueAfter and DueBefore keep tasks due in [DueAfter, DueBefore); tasks without a due date are dropped
+	DueAfter  *time.Time
+	DueBefore *time.Time
+
+	// SortBy orders the result; ties are broken by ID. Tasks without a value
+	// for the field (e.g. no due date) come last in both directions.
+	SortBy     SortField
+	Descending bool
+
+	// Limit caps the page size; zero returns every match
+	Limit int
+	// Cursor continues from the page that returned it
+	Cursor string
+}
+
+// TaskPage is one page of a query
+type TaskPage struct {
+	Tasks []Task
+	// NextCursor fetches the following page; empty on the last page
+	NextCursor string
+}
+
+// sortKey is the value a task is ordered by
+type sortKey struct {
+	Null bool   `json:"null,omitempty"`
+	Str  string `json:"s,omitempty"`
+	Num  int64  `json:"n,omitempty"`
+}
+
+// cursor records the position of the last task on a page. Positions are
+// sort values rather than offsets, so adding or deleting tasks does not
+// shift later pages.
+type cursor struct {
+	SortBy     SortField `json:"sort"`
+	Descending bool      `json:"desc,omitempty"`
+	Key        sortKey   `json:"key"`
+	ID         int       `json:"id"`
+}
+
+// QueryTasks returns the tasks matching opts, returns an error if the sort field or cursor is invalid or the
+// store cannot be read
+func (tm *TaskManager) QueryTasks(opts ListOptions) (TaskPage, error) {
+	if !opts.SortBy.Valid() {
+		return TaskPage{}, fmt.Errorf("%w: %q", ErrInvalidSortField, opts.SortBy)
+	}
+	if opts.SortBy == "" {
+		opts.SortBy = SortByID
+	}
+
+	var after *cursor
+	if opts.Cursor != "" {
+		c, err := decodeCursor(opts.Cursor)
+		if err != nil || c.SortBy != opts.SortBy || c.Descending != opts.Descending {
+			return TaskPage{}, ErrInvalidCursor
+		}
+		after = &c
+	}
+
+	all, err := tm.store.List()
+	if err != nil {
+		return TaskPage{}, err
+	}
+
+	tasks := make([]Task, 0, len(all))
+	for _, task := range all {
+		if opts.matches(task) {
+			tasks = append(tasks, task)
+		}
+	}
+
+	slices.SortFunc(tasks, func(a, b Task) int {
+		return compareKeys(keyOf(a, opts.SortBy), a.ID, keyOf(b, opts.SortBy), b.ID, opts.Descending)
+	})
+
+	if after != nil {
+		start, _ := slices.BinarySearchFunc(tasks, after, func(task Task, c *cursor) int {
+			if compareKeys(keyOf(task, opts.SortBy), task.ID, c.Key, c.ID, opts.Descending) <= 0 {
+				return -1
+			}
+			return 1
+		})
+		tasks = tasks[start:]
+	}
+
+	page := TaskPage{Tasks: tasks}
+	if opts.Limit > 0 && len(tasks) > opts.Limit {
+		page.Tasks = tasks[:opts.Limit]
+		last := page.Tasks[len(page.Tasks)-1]
+		page.NextCursor = encodeCursor(cursor{
+			SortBy:     opts.SortBy,
+			Descending: opts.Descending,
+			Key:        keyOf(last, opts.SortBy),
+			ID:         last.ID,
+		})
+	}
+	return page, nil
+}
+
+// matches reports whether task passes every filter in opts
+func (opts ListOptions) matches(task Task) bool {
+	if opts.Done != nil && task.Done != *opts.Done {
+		return false
+	}
+	if opts.Text != "" {
+		text := strings.ToLower(opts.Text)
+		if !strings.Contains(strings.ToLower(task.Title), text) && !strings.Contains(strings.ToLower(task.Description), text) {
+			return false
+		}
+	}
+	for _, want := range opts.Tags {
+		if !slices.ContainsFunc(task.Tags, func(tag string) bool { return strings.EqualFold(tag, want) }) {
+			return false
+		}
+	}
+	if len(opts.Priorities) > 0 && !slices.Contains(opts.Priorities, task.Priority) {
+		return false
+	}
+	if opts.DueAfter != nil || opts.DueBefore != nil {
+		if task.DueDate == nil {
+			return false
+		}
+		if opts.DueAfter != nil && task.DueDate.Before(*opts.DueAfter) {
+			return false
+		}
+		if opts.DueBefore != nil && !task.DueDate.Before(*opts.DueBefore) {
+			return false
+		}
+	}
+	return true
+}
+
+// keyOf returns the value of field for task
+func keyOf(task Task, field SortField) sortKey {
+	switch field {
+	case SortByTitle:
+		return sortKey{Str: strings.ToLower(task.Title)}
+	case SortByDone:
+		if task.Done {
+			return sortKey{Num: 1}
+		}
+		return sortKey{}
+	case SortByPriority:
+		return sortKey{Num: int64(task.Priority)}
+	case SortByDueDate:
+		return timeKey(task.DueDate)
+	case SortByCreatedAt:
+		return timeKey(&task.CreatedAt)
+	case SortByUpdatedAt:
+		return timeKey(&task.UpdatedAt)
+	case SortByCompletedAt:
+		return timeKey(task.CompletedAt)
+	default:
+		return sortKey{Num: int64(task.ID)}
+	}
+}
+
+func timeKey(t *time.Time) sortKey {
+	if t == nil {
+		return sortKey{Null: true}
+	}
+	return sortKey{Num: t.UnixNano()}
+}
+
+// compareKeys orders two positions: missing values last, then by key in the
+// requested direction, then by ascending ID
+func compareKeys(a sortKey, aID int, b sortKey, bID int, descending bool) int {
+	if a.Null != b.Null {
+		if a.Null {
+			return 1
+		}
+		return -1
+	}
+
+	c := cmp.Or(cmp.Compare(a.Num, b.Num), strings.Compare(a.Str, b.Str))
+	if descending {
+		c = -c
+	}
+	return cmp.Or(c, cmp.Compare(aID, bID))
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(data, &c)
+	return c, err
+}
@@ -0,0 +1,160 @@
+package taskmanager
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func date(day int) time.Time {
+	return time.Date(2025, 6, day, 0, 0, 0, 0, time.UTC)
+}
+
+// newQueryManager returns a manager holding:
+//
+//	1 "Write report"  high    [work]        due June 5
+//	2 "Buy milk"      low     [home]        no due date
+//	3 "Review PR"     medium  [work, code]  due June 2, done
+//	4 "Fix bike"      none    [home]        due June 9
+func newQueryManager(t *testing.T) *TaskManager {
+	t.Helper()
+
+	tm := newTestManager()
+	tm.AddTask("Write report", "quarterly numbers", WithPriority(PriorityHigh), WithTags("work"), WithDueDate(date(5)))
+	tm.AddTask("Buy milk", "", WithPriority(PriorityLow), WithTags("home"))
+	tm.AddTask("Review PR", "taskmanager REPORT", WithPriority(PriorityMedium), WithTags("work", "code"), WithDueDate(date(2)))
+	tm.AddTask("Fix bike", "", WithTags("Home"), WithDueDate(date(9)))
+	if _, err := tm.UpdateTask(3, TaskPatch{Done: ptr(true)}); err != nil {
+		t.Fatalf("Failed to complete task: %v", err)
+	}
+	return tm
+}
+
+func ids(tasks []Task) []int {
+	result := []int{}
+	for _, task := range tasks {
+		result = append(result, task.ID)
+	}
+	return result
+}
+
+func TestQueryTasksFilters(t *testing.T) {
+	tm := newQueryManager(t)
+
+	tests := []struct {
+		name     string
+		opts     ListOptions
+		expected []int
+	}{
+		{name: "no filter", opts: ListOptions{}, expected: []int{1, 2, 3, 4}},
+		{name: "open only", opts: ListOptions{Done: ptr(false)}, expected: []int{1, 2, 4}},
+		{name: "text in title or description", opts: ListOptions{Text: "report"}, expected: []int{1, 3}},
+		{name: "single tag ignores case", opts: ListOptions{Tags: []string{"home"}}, expected: []int{2, 4}},
+		{name: "all tags must match", opts: ListOptions{Tags: []string{"work", "code"}}, expected: []int{3}},
+		{name: "any priority", opts: ListOptions{Priorities: []Priority{PriorityHigh, PriorityNone}}, expected: []int{1, 4}},
+		{name: "due range is half open", opts: ListOptions{DueAfter: ptr(date(2)), DueBefore: ptr(date(9))}, expected: []int{1, 3}},
+		{name: "due after drops undated tasks", opts: ListOptions{DueAfter: ptr(date(1))}, expected: []int{1, 3, 4}},
+		{name: "combined", opts: ListOptions{Tags: []string{"work"}, Done: ptr(false)}, expected: []int{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := tm.QueryTasks(tt.opts)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := ids(page.Tasks); !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected tasks %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestQueryTasksSort(t *testing.T) {
+	tm := newQueryManager(t)
+
+	tests := []struct {
+		sortBy     SortField
+		descending bool
+		expected   []int
+	}{
+		{sortBy: SortByTitle, expected: []int{2, 4, 3, 1}},
+		{sortBy: SortByPriority, descending: true, expected: []int{1, 3, 2, 4}},
+		{sortBy: SortByDueDate, expected: []int{3, 1, 4, 2}},
+		{sortBy: SortByDueDate, descending: true, expected: []int{4, 1, 3, 2}},
+		{sortBy: SortByDone, expected: []int{1, 2, 4, 3}},
+		{sortBy: SortByUpdatedAt, descending: true, expected: []int{3, 4, 2, 1}},
+		{sortBy: SortByCompletedAt, expected: []int{3, 1, 2, 4}},
+	}
+
+	for _, tt := range tests {
+		page, err := tm.QueryTasks(ListOptions{SortBy: tt.sortBy, Descending: tt.descending})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got := ids(page.Tasks); !slices.Equal(got, tt.expected) {
+			t.Errorf("Sort by %s (desc=%v): expected %v, got %v", tt.sortBy, tt.descending, tt.expected, got)
+		}
+	}
+
+	if _, err := tm.QueryTasks(ListOptions{SortBy: "colour"}); !errors.Is(err, ErrInvalidSortField) {
+		t.Errorf("Expected ErrInvalidSortField, got %v", err)
+	}
+}
+
+func TestQueryTasksCursorIsStable(t *testing.T) {
+	tm := newQueryManager(t)
+	opts := ListOptions{SortBy: SortByTitle, Limit: 2}
+
+	page, err := tm.QueryTasks(opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := ids(page.Tasks); !slices.Equal(got, []int{2, 4}) || page.NextCursor == "" {
+		t.Fatalf("Expected first page [2 4] with a cursor, got %v %q", got, page.NextCursor)
+	}
+
+	// Changes before the cursor and deleting the last task seen must not shift the next page
+	tm.AddTask("Apply patch", "")
+	tm.DeleteTask(4)
+	tm.AddTask("Zip logs", "")
+
+	opts.Cursor = page.NextCursor
+	page, err = tm.QueryTasks(opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := ids(page.Tasks); !slices.Equal(got, []int{3, 1}) {
+		t.Errorf("Expected second page [3 1], got %v", got)
+	}
+
+	opts.Cursor = page.NextCursor
+	page, _ = tm.QueryTasks(opts)
+	if got := ids(page.Tasks); !slices.Equal(got, []int{6}) || page.NextCursor != "" {
+		t.Errorf("Expected last page [6] without a cursor, got %v %q", got, page.NextCursor)
+	}
+}
+
+func TestQueryTasksRejectsBadCursor(t *testing.T) {
+	tm := newQueryManager(t)
+
+	page, _ := tm.QueryTasks(ListOptions{SortBy: SortByTitle, Limit: 1})
+
+	tests := []struct {
+		name string
+		opts ListOptions
+	}{
+		{name: "garbage", opts: ListOptions{Cursor: "not a cursor"}},
+		{name: "different sort field", opts: ListOptions{SortBy: SortByPriority, Cursor: page.NextCursor}},
+		{name: "different direction", opts: ListOptions{SortBy: SortByTitle, Descending: true, Cursor: page.NextCursor}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tm.QueryTasks(tt.opts); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("Expected ErrInvalidCursor, got %v", err)
+			}
+		})
+	}
+}
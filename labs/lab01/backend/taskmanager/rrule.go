@@ -0,0 +1,372 @@
+package taskmanager
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recurrence errors
+var (
+	ErrInvalidRecurrence      = errors.New("invalid recurrence rule")
+	ErrRecurrenceNeedsDueDate = errors.New("recurring task needs a due date")
+)
+
+// Frequency is the base period of a recurrence rule
+type Frequency string
+
+// Supported frequencies
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+// maxPeriods bounds how many empty periods the expander walks before giving up,
+// so a rule that can never match again cannot loop forever
+const maxPeriods = 10000
+
+// WeekdayNum is a BYDAY entry such as MO, 2TU or -1FR. N is the ordinal
+// within the month (negative counts from the end); zero means every such day.
+type WeekdayNum struct {
+	N       int
+	Weekday time.Weekday
+}
+
+var weekdayCodes = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// String returns the iCalendar form of the entry
+func (w WeekdayNum) String() string {
+	if w.N == 0 {
+		return weekdayCodes[w.Weekday]
+	}
+	return strconv.Itoa(w.N) + weekdayCodes[w.Weekday]
+}
+
+// RRule is the subset of an RFC 5545 recurrence rule supported by tasks:
+// FREQ=DAILY|WEEKLY|MONTHLY with INTERVAL, BYDAY, COUNT and UNTIL. Weeks start
+// on Monday. The task's due date is the rule's DTSTART and always counts as
+// the first occurrence.
+type RRule struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []WeekdayNum
+	// Count limits the total number of occurrences; zero means unlimited
+	Count int
+	// Until is the last instant an occurrence may fall on; zero means unlimited
+	Until time.Time
+	// UntilDate makes Until a calendar date that includes the whole day
+	UntilDate bool
+}
+
+// ParseRRule parses a rule such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;COUNT=10".
+// An "RRULE:" prefix is accepted.
+func ParseRRule(s string) (RRule, error) {
+	rule := RRule{Interval: 1}
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return RRule{}, fmt.Errorf("%w: empty rule", ErrInvalidRecurrence)
+	}
+
+	seen := map[string]bool{}
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		key = strings.ToUpper(key)
+		if !ok || value == "" {
+			return RRule{}, fmt.Errorf("%w: malformed part %q", ErrInvalidRecurrence, part)
+		}
+		if seen[key] {
+			return RRule{}, fmt.Errorf("%w: duplicate %s", ErrInvalidRecurrence, key)
+		}
+		seen[key] = true
+
+		var err error
+		switch key {
+		case "FREQ":
+			rule.Freq = Frequency(strings.ToUpper(value))
+			if rule.Freq != Daily && rule.Freq != Weekly && rule.Freq != Monthly {
+				err = fmt.Errorf("unsupported FREQ %q", value)
+			}
+		case "INTERVAL":
+			rule.Interval, err = positiveInt(value)
+		case "COUNT":
+			rule.Count, err = positiveInt(value)
+		case "UNTIL":
+			rule.Until, rule.UntilDate, err = parseUntil(value)
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(value)
+		default:
+			err = fmt.Errorf("unsupported part %s", key)
+		}
+		if err != nil {
+			return RRule{}, fmt.Errorf("%w: %s: %v", ErrInvalidRecurrence, key, err)
+		}
+	}
+
+	if err := rule.Validate(); err != nil {
+		return RRule{}, err
+	}
+	return rule, nil
+}
+
+// Validate checks the combination of parts
+func (r RRule) Validate() error {
+	switch {
+	case r.Freq != Daily && r.Freq != Weekly && r.Freq != Monthly:
+		return fmt.Errorf("%w: FREQ must be DAILY, WEEKLY or MONTHLY", ErrInvalidRecurrence)
+	case r.Interval < 1:
+		return fmt.Errorf("%w: INTERVAL must be positive", ErrInvalidRecurrence)
+	case r.Count < 0:
+		return fmt.Errorf("%w: COUNT must be positive", ErrInvalidRecurrence)
+	case r.Count > 0 && !r.Until.IsZero():
+		return fmt.Errorf("%w: COUNT and UNTIL cannot be combined", ErrInvalidRecurrence)
+	}
+	for _, day := range r.ByDay {
+		if day.N != 0 && r.Freq != Monthly {
+			return fmt.Errorf("%w: BYDAY ordinals require FREQ=MONTHLY", ErrInvalidRecurrence)
+		}
+		if day.N < -5 || day.N > 5 {
+			return fmt.Errorf("%w: BYDAY ordinal %d out of range", ErrInvalidRecurrence, day.N)
+		}
+	}
+	return nil
+}
+
+// String returns the rule in canonical iCalendar form
+func (r RRule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			days[i] = day.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		if r.UntilDate {
+			parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+		} else {
+			parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// MarshalText encodes the rule in iCalendar form
+func (r RRule) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText decodes a rule in iCalendar form
+func (r *RRule) UnmarshalText(text []byte) error {
+	parsed, err := ParseRRule(string(text))
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// Next returns the first occurrence of the series starting at dtstart that
+// falls strictly after after, or false if the series has ended
+func (r RRule) Next(dtstart, after time.Time) (time.Time, bool) {
+	var next time.Time
+	found := false
+	r.each(dtstart, func(t time.Time) bool {
+		if t.After(after) {
+			next, found = t, true
+			return false
+		}
+		return true
+	})
+	return next, found
+}
+
+// Between returns the occurrences of the series starting at dtstart in [from, to)
+func (r RRule) Between(dtstart, from, to time.Time) []time.Time {
+	occurrences := []time.Time{}
+	r.each(dtstart, func(t time.Time) bool {
+		if !t.Before(to) {
+			return false
+		}
+		if !t.Before(from) {
+			occurrences = append(occurrences, t)
+		}
+		return true
+	})
+	return occurrences
+}
+
+// each calls yield with every occurrence in order until it returns false or
+// the series ends. Occurrences keep dtstart's clock time and location.
+func (r RRule) each(dtstart time.Time, yield func(time.Time) bool) {
+	interval := max(r.Interval, 1)
+	count := 0
+	emit := func(t time.Time) bool {
+		if r.ended(t) {
+			return false
+		}
+		count++
+		if !yield(t) {
+			return false
+		}
+		return r.Count == 0 || count < r.Count
+	}
+
+	if !emit(dtstart) {
+		return
+	}
+	for period, empty := 0, 0; empty < maxPeriods; period += interval {
+		candidates := r.candidates(dtstart, period)
+		if len(candidates) == 0 {
+			empty++
+			continue
+		}
+		empty = 0
+		for _, t := range candidates {
+			if !t.After(dtstart) {
+				continue
+			}
+			if !emit(t) {
+				return
+			}
+		}
+	}
+}
+
+// ended reports whether t is past UNTIL
+func (r RRule) ended(t time.Time) bool {
+	if r.Until.IsZero() {
+		return false
+	}
+	if r.UntilDate {
+		y, m, d := t.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC).After(r.Until)
+	}
+	return t.After(r.Until)
+}
+
+// candidates returns the sorted occurrences in the period that starts the
+// given number of days, weeks or months after dtstart's period
+func (r RRule) candidates(dtstart time.Time, period int) []time.Time {
+	switch r.Freq {
+	case Daily:
+		day := dtstart.AddDate(0, 0, period)
+		if len(r.ByDay) > 0 && !slices.ContainsFunc(r.ByDay, func(w WeekdayNum) bool { return w.Weekday == day.Weekday() }) {
+			return nil
+		}
+		return []time.Time{day}
+
+	case Weekly:
+		// Monday of dtstart's week, shifted by period weeks
+		monday := dtstart.AddDate(0, 0, -daysSinceMonday(dtstart.Weekday())+7*period)
+		if len(r.ByDay) == 0 {
+			return []time.Time{monday.AddDate(0, 0, daysSinceMonday(dtstart.Weekday()))}
+		}
+		days := []time.Time{}
+		for _, w := range r.ByDay {
+			days = append(days, monday.AddDate(0, 0, daysSinceMonday(w.Weekday)))
+		}
+		return sortedUnique(days)
+
+	case Monthly:
+		year, month, _ := dtstart.Date()
+		first := time.Date(year, month+time.Month(period), 1, dtstart.Hour(), dtstart.Minute(), dtstart.Second(), dtstart.Nanosecond(), dtstart.Location())
+		if len(r.ByDay) == 0 {
+			day := first.AddDate(0, 0, dtstart.Day()-1)
+			if day.Month() != first.Month() {
+				// Months without this day are skipped, as RFC 5545 requires
+				return nil
+			}
+			return []time.Time{day}
+		}
+		days := []time.Time{}
+		for _, w := range r.ByDay {
+			days = append(days, monthlyWeekdays(first, w)...)
+		}
+		return sortedUnique(days)
+	}
+	return nil
+}
+
+// monthlyWeekdays returns the days of first's month matching w
+func monthlyWeekdays(first time.Time, w WeekdayNum) []time.Time {
+	matches := []time.Time{}
+	offset := (int(w.Weekday) - int(first.Weekday()) + 7) % 7
+	for day := first.AddDate(0, 0, offset); day.Month() == first.Month(); day = day.AddDate(0, 0, 7) {
+		matches = append(matches, day)
+	}
+
+	switch {
+	case w.N == 0:
+		return matches
+	case w.N > 0 && w.N <= len(matches):
+		return matches[w.N-1 : w.N]
+	case w.N < 0 && -w.N <= len(matches):
+		i := len(matches) + w.N
+		return matches[i : i+1]
+	}
+	return nil
+}
+
+func daysSinceMonday(d time.Weekday) int {
+	return (int(d) + 6) % 7
+}
+
+func sortedUnique(days []time.Time) []time.Time {
+	slices.SortFunc(days, func(a, b time.Time) int { return a.Compare(b) })
+	return slices.CompactFunc(days, func(a, b time.Time) bool { return a.Equal(b) })
+}
+
+func positiveInt(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%q is not a positive integer", s)
+	}
+	return n, nil
+}
+
+// parseUntil accepts a DATE (20250630) or a UTC DATE-TIME (20250630T120000Z)
+func parseUntil(s string) (time.Time, bool, error) {
+	if t, err := time.Parse("20060102", s); err == nil {
+		return t, true, nil
+	}
+	if t, err := time.Parse("20060102T150405Z", s); err == nil {
+		return t, false, nil
+	}
+	return time.Time{}, false, fmt.Errorf("%q is not a DATE or UTC DATE-TIME", s)
+}
+
+func parseByDay(s string) ([]WeekdayNum, error) {
+	days := []WeekdayNum{}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.ToUpper(strings.TrimSpace(item))
+		if len(item) < 2 {
+			return nil, fmt.Errorf("invalid day %q", item)
+		}
+
+		code, ordinal := item[len(item)-2:], item[:len(item)-2]
+		weekday := slices.Index(weekdayCodes, code)
+		if weekday < 0 {
+			return nil, fmt.Errorf("invalid day %q", item)
+		}
+		n := 0
+		if ordinal != "" {
+			var err error
+			if n, err = strconv.Atoi(ordinal); err != nil || n == 0 {
+				return nil, fmt.Errorf("invalid day %q", item)
+			}
+		}
+		days = append(days, WeekdayNum{N: n, Weekday: time.Weekday(weekday)})
+	}
+	return days, nil
+}
@@ -0,0 +1,199 @@
+package taskmanager
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func at(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 9, 0, 0, 0, time.UTC)
+}
+
+func TestParseRRule(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "FREQ=DAILY", expected: "FREQ=DAILY"},
+		{input: "RRULE:freq=weekly;interval=2;byday=mo,we", expected: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE"},
+		{input: "FREQ=MONTHLY;BYDAY=-1FR,+2TU;COUNT=6", expected: "FREQ=MONTHLY;BYDAY=-1FR,2TU;COUNT=6"},
+		{input: "FREQ=DAILY;INTERVAL=1;UNTIL=20250630", expected: "FREQ=DAILY;UNTIL=20250630"},
+		{input: "FREQ=WEEKLY;UNTIL=20250630T120000Z", expected: "FREQ=WEEKLY;UNTIL=20250630T120000Z"},
+	}
+
+	for _, tt := range tests {
+		rule, err := ParseRRule(tt.input)
+		if err != nil {
+			t.Errorf("ParseRRule(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if got := rule.String(); got != tt.expected {
+			t.Errorf("ParseRRule(%q).String() = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=YEARLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20250101",
+		"FREQ=WEEKLY;BYDAY=2MO",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=DAILY;BYDAY=XX",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;BYSETPOS=1",
+		"FREQ=DAILY;UNTIL=tomorrow",
+	}
+	for _, input := range invalid {
+		if _, err := ParseRRule(input); !errors.Is(err, ErrInvalidRecurrence) {
+			t.Errorf("ParseRRule(%q): expected ErrInvalidRecurrence, got %v", input, err)
+		}
+	}
+}
+
+func TestRRuleExpansion(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		dtstart  time.Time
+		expected []time.Time
+	}{
+		{
+			name:     "daily with interval",
+			rule:     "FREQ=DAILY;INTERVAL=2;COUNT=4",
+			dtstart:  at(2025, 6, 1),
+			expected: []time.Time{at(2025, 6, 1), at(2025, 6, 3), at(2025, 6, 5), at(2025, 6, 7)},
+		},
+		{
+			name:     "daily limited to weekend until a date-time",
+			rule:     "FREQ=DAILY;BYDAY=SA,SU;UNTIL=20250615T000000Z",
+			dtstart:  at(2025, 6, 7),
+			expected: []time.Time{at(2025, 6, 7), at(2025, 6, 8), at(2025, 6, 14)},
+		},
+		{
+			name:     "weekly on several days",
+			rule:     "FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=5",
+			dtstart:  at(2025, 6, 2),
+			expected: []time.Time{at(2025, 6, 2), at(2025, 6, 4), at(2025, 6, 6), at(2025, 6, 9), at(2025, 6, 11)},
+		},
+		{
+			name:     "every other week",
+			rule:     "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH;COUNT=5",
+			dtstart:  at(2025, 6, 3),
+			expected: []time.Time{at(2025, 6, 3), at(2025, 6, 5), at(2025, 6, 17), at(2025, 6, 19), at(2025, 7, 1)},
+		},
+		{
+			name:     "monthly skips short months",
+			rule:     "FREQ=MONTHLY;COUNT=4",
+			dtstart:  at(2025, 1, 31),
+			expected: []time.Time{at(2025, 1, 31), at(2025, 3, 31), at(2025, 5, 31), at(2025, 7, 31)},
+		},
+		{
+			name:     "monthly on the last friday",
+			rule:     "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3",
+			dtstart:  at(2025, 6, 27),
+			expected: []time.Time{at(2025, 6, 27), at(2025, 7, 25), at(2025, 8, 29)},
+		},
+		{
+			name:     "monthly on the second tuesday until a date",
+			rule:     "FREQ=MONTHLY;BYDAY=2TU;UNTIL=20250901",
+			dtstart:  at(2025, 6, 10),
+			expected: []time.Time{at(2025, 6, 10), at(2025, 7, 8), at(2025, 8, 12)},
+		},
+		{
+			name:     "until a date includes that day",
+			rule:     "FREQ=DAILY;UNTIL=20250603",
+			dtstart:  at(2025, 6, 1),
+			expected: []time.Time{at(2025, 6, 1), at(2025, 6, 2), at(2025, 6, 3)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRRule(tt.rule)
+			if err != nil {
+				t.Fatalf("Failed to parse rule: %v", err)
+			}
+
+			got := rule.Between(tt.dtstart, tt.dtstart, at(2026, 1, 1))
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Expected %d occurrences, got %v", len(tt.expected), got)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.expected[i]) {
+					t.Errorf("Occurrence %d: expected %v, got %v", i, tt.expected[i], got[i])
+				}
+			}
+
+			// Next walks the same series one step at a time
+			for i := 0; i+1 < len(tt.expected); i++ {
+				next, ok := rule.Next(tt.dtstart, tt.expected[i])
+				if !ok || !next.Equal(tt.expected[i+1]) {
+					t.Errorf("Next after %v: expected %v, got %v (%v)", tt.expected[i], tt.expected[i+1], next, ok)
+				}
+			}
+			if next, ok := rule.Next(tt.dtstart, tt.expected[len(tt.expected)-1]); ok {
+				t.Errorf("Expected the series to end, got %v", next)
+			}
+		})
+	}
+}
+
+func TestRRuleKeepsLocalTimeAcrossDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	rule, _ := ParseRRule("FREQ=DAILY;COUNT=3")
+	dtstart := time.Date(2025, 3, 29, 9, 0, 0, 0, berlin)
+	for _, occurrence := range rule.Between(dtstart, dtstart, dtstart.AddDate(0, 0, 7)) {
+		if occurrence.Hour() != 9 || occurrence.Location() != berlin {
+			t.Errorf("Expected 09:00 Berlin time, got %v", occurrence)
+		}
+	}
+}
+
+func TestCompletingRecurringTaskSchedulesNext(t *testing.T) {
+	tm := newTestManager()
+	rule, _ := ParseRRule("FREQ=WEEKLY;BYDAY=MO;COUNT=2")
+
+	first, err := tm.AddTask("Take out bins", "", WithDueDate(at(2025, 6, 2)), WithRecurrence(rule), WithTags("chores"))
+	if err != nil {
+		t.Fatalf("Failed to add task: %v", err)
+	}
+
+	if _, err := tm.UpdateTask(first.ID, TaskPatch{Done: ptr(true)}); err != nil {
+		t.Fatalf("Failed to complete task: %v", err)
+	}
+	open := tm.ListTasks(ptr(false))
+	if len(open) != 1 {
+		t.Fatalf("Expected one new occurrence, got %+v", open)
+	}
+	next := open[0]
+	if next.Title != "Take out bins" || next.DueDate == nil || !next.DueDate.Equal(at(2025, 6, 9)) || len(next.Tags) != 1 {
+		t.Errorf("Unexpected next occurrence: %+v", next)
+	}
+	if next.Recurrence == nil || next.Recurrence.Count != 1 {
+		t.Errorf("Expected the remaining count to be 1, got %v", next.Recurrence)
+	}
+
+	// Reopening and completing again must not schedule twice
+	tm.UpdateTask(first.ID, TaskPatch{Done: ptr(false)})
+	tm.UpdateTask(first.ID, TaskPatch{Done: ptr(true)})
+	if got := len(tm.ListTasks(nil)); got != 2 {
+		t.Errorf("Expected 2 tasks, got %d", got)
+	}
+
+	// The last occurrence ends the series
+	tm.UpdateTask(next.ID, TaskPatch{Done: ptr(true)})
+	if got := len(tm.ListTasks(nil)); got != 2 {
+		t.Errorf("Expected no occurrence after the last one, got %d tasks", got)
+	}
+
+	if _, err := tm.AddTask("No anchor", "", WithRecurrence(rule)); err != ErrRecurrenceNeedsDueDate {
+		t.Errorf("Expected ErrRecurrenceNeedsDueDate, got %v", err)
+	}
+}
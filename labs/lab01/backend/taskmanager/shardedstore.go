@@ -0,0 +1,177 @@
+package taskmanager
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultShards is the shard count used by NewShardedMemoryStore when n <= 0
+const DefaultShards = 16
+
+// ShardedMemoryStore is an in-memory store that spreads tasks over several
+// independently locked maps, so operations on different tasks rarely contend
+type ShardedMemoryStore struct {
+	shards []memoryShard
+	lastID atomic.Int64
+}
+
+type memoryShard struct {
+	mu    sync.RWMutex
+	tasks map[int]Task
+	trash map[int]DeletedTask
+}
+
+// NewShardedMemoryStore creates an empty store with n shards
+func NewShardedMemoryStore(n int) *ShardedMemoryStore {
+	if n <= 0 {
+		n = DefaultShards
+	}
+
+	s := &ShardedMemoryStore{shards: make([]memoryShard, n)}
+	for i := range s.shards {
+		s.shards[i].tasks = make(map[int]Task)
+		s.shards[i].trash = make(map[int]DeletedTask)
+	}
+	return s
+}
+
+// shard returns the shard holding id
+func (s *ShardedMemoryStore) shard(id int) *memoryShard {
+	return &s.shards[uint(id)%uint(len(s.shards))]
+}
+
+// Create stores a new task under the next ID
+func (s *ShardedMemoryStore) Create(task Task) (Task, error) {
+	task.ID = int(s.lastID.Add(1))
+
+	shard := s.shard(task.ID)
+	shard.mu.Lock()
+	shard.tasks[task.ID] = task.clone()
+	shard.mu.Unlock()
+	return task, nil
+}
+
+// Get returns the task with the given ID
+func (s *ShardedMemoryStore) Get(id int) (Task, error) {
+	shard := s.shard(id)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	task, ok := shard.tasks[id]
+	if !ok {
+		return Task{}, ErrTaskNotFound
+	}
+	return task.clone(), nil
+}
+
+// Update replaces the stored task with the same ID
+func (s *ShardedMemoryStore) Update(task Task) error {
+	shard := s.shard(task.ID)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if _, ok := shard.tasks[task.ID]; !ok {
+		return ErrTaskNotFound
+	}
+	shard.tasks[task.ID] = task.clone()
+	return nil
+}
+
+// Delete removes the task with the given ID
+func (s *ShardedMemoryStore) Delete(id int) error {
+	shard := s.shard(id)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if _, ok := shard.tasks[id]; !ok {
+		return ErrTaskNotFound
+	}
+	delete(shard.tasks, id)
+	return nil
+}
+
+// Trash moves the task with the given ID to the trash
+func (s *ShardedMemoryStore) Trash(id int, at time.Time) error {
+	shard := s.shard(id)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	task, ok := shard.tasks[id]
+	if !ok {
+		return ErrTaskNotFound
+	}
+	delete(shard.tasks, id)
+	shard.trash[id] = DeletedTask{Task: task, DeletedAt: at}
+	return nil
+}
+
+// Trashed returns the tasks in the trash ordered by ID
+func (s *ShardedMemoryStore) Trashed() ([]DeletedTask, error) {
+	trashed := []DeletedTask{}
+	for i := range s.shards {
+		shard := &s.shards[i]
+		shard.mu.RLock()
+		for _, deleted := range shard.trash {
+			deleted.Task = deleted.Task.clone()
+			trashed = append(trashed, deleted)
+		}
+		shard.mu.RUnlock()
+	}
+
+	sort.Slice(trashed, func(i, j int) bool {
+		return trashed[i].Task.ID < trashed[j].Task.ID
+	})
+	return trashed, nil
+}
+
+// Purge permanently removes a task from the trash
+func (s *ShardedMemoryStore) Purge(id int) error {
+	shard := s.shard(id)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if _, ok := shard.trash[id]; !ok {
+		return ErrNotInTrash
+	}
+	delete(shard.trash, id)
+	return nil
+}
+
+// Restore stores a deleted task under its own ID
+func (s *ShardedMemoryStore) Restore(task Task) error {
+	shard := s.shard(task.ID)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if _, ok := shard.tasks[task.ID]; ok {
+		return ErrTaskExists
+	}
+	delete(shard.trash, task.ID)
+	shard.tasks[task.ID] = task.clone()
+	// Keep the counter ahead of every stored ID
+	for last := s.lastID.Load(); int64(task.ID) > last && !s.lastID.CompareAndSwap(last, int64(task.ID)); {
+		last = s.lastID.Load()
+	}
+	return nil
+}
+
+// List returns every task ordered by ID. Shards are read one at a time, so
+// the result is not a single point-in-time snapshot under concurrent writes.
+func (s *ShardedMemoryStore) List() ([]Task, error) {
+	tasks := []Task{}
+	for i := range s.shards {
+		shard := &s.shards[i]
+		shard.mu.RLock()
+		for _, task := range shard.tasks {
+			tasks = append(tasks, task.clone())
+		}
+		shard.mu.RUnlock()
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].ID < tasks[j].ID
+	})
+	return tasks, nil
+}
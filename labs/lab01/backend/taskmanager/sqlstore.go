@@ -0,0 +1,362 @@
+package taskmanager
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Dialect selects the SQL flavour used by SQLStore
+type Dialect int
+
+// Supported SQL dialects
+const (
+	DialectPostgres Dialect = iota
+	DialectSQLite
+)
+
+// SQLStore keeps tasks in a "tasks" table of a SQL database. Trashed tasks
+// stay in the table with deleted_at set until they are purged.
+type SQLStore struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// NewSQLStore creates a store on top of db. The tasks table must exist; see CreateSchema.
+func NewSQLStore(db *sql.DB, dialect Dialect) *SQLStore {
+	return &SQLStore{db: db, dialect: dialect}
+}
+
+// CreateSchema creates the tasks table if it does not exist yet. Existing
+// tables are not altered; the backend migrations keep them up to date.
+func (s *SQLStore) CreateSchema() error {
+	var ddl string
+	switch s.dialect {
+	case DialectPostgres:
+		ddl = `CREATE TABLE IF NOT EXISTS tasks (
+			id BIGSERIAL PRIMARY KEY,
+			title TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			done BOOLEAN NOT NULL DEFAULT FALSE,
+			priority SMALLINT NOT NULL DEFAULT 0,
+			tags TEXT NOT NULL DEFAULT '[]',
+			due_date TIMESTAMPTZ,
+			recurrence TEXT,
+			parent_id BIGINT REFERENCES tasks (id),
+			blocked_by TEXT NOT NULL DEFAULT '[]',
+			created_at TIMESTAMPTZ NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL,
+			completed_at TIMESTAMPTZ,
+			deleted_at TIMESTAMPTZ
+		)`
+	case DialectSQLite:
+		// AUTOINCREMENT stops SQLite from reusing the IDs of deleted rows
+		ddl = `CREATE TABLE IF NOT EXISTS tasks (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			title TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			done BOOLEAN NOT NULL DEFAULT FALSE,
+			priority INTEGER NOT NULL DEFAULT 0,
+			tags TEXT NOT NULL DEFAULT '[]',
+			due_date DATETIME,
+			recurrence TEXT,
+			parent_id INTEGER REFERENCES tasks (id),
+			blocked_by TEXT NOT NULL DEFAULT '[]',
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL,
+			completed_at DATETIME,
+			deleted_at DATETIME
+		)`
+	default:
+		return fmt.Errorf("unknown SQL dialect %d", s.dialect)
+	}
+
+	if _, err := s.db.Exec(ddl); err != nil {
+		return fmt.Errorf("create tasks table: %w", err)
+	}
+	return nil
+}
+
+// taskColumns lists the columns read by scanTask, in order
+const taskColumns = `id, title, description, done, priority, tags, due_date, recurrence, parent_id, blocked_by, created_at, updated_at, completed_at`
+
+// Create inserts a new task and returns it with the ID assigned by the database
+func (s *SQLStore) Create(task Task) (Task, error) {
+	tags, blockedBy, err := encodeLists(task)
+	if err != nil {
+		return Task{}, err
+	}
+
+	err = s.db.QueryRow(
+		`INSERT INTO tasks (title, description, done, priority, tags, due_date, recurrence, parent_id, blocked_by, created_at, updated_at, completed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id`,
+		task.Title, task.Description, task.Done, int(task.Priority), tags, nullTime(task.DueDate),
+		nullRule(task.Recurrence), nullID(task.ParentID), blockedBy, task.CreatedAt.UTC(), task.UpdatedAt.UTC(), nullTime(task.CompletedAt),
+	).Scan(&task.ID)
+	if err != nil {
+		return Task{}, fmt.Errorf("insert task: %w", err)
+	}
+	return task, nil
+}
+
+// Get returns the task with the given ID
+func (s *SQLStore) Get(id int) (Task, error) {
+	row := s.db.QueryRow(`SELECT `+taskColumns+` FROM tasks WHERE id = $1 AND deleted_at IS NULL`, id)
+
+	task, err := scanTask(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Task{}, ErrTaskNotFound
+	}
+	if err != nil {
+		return Task{}, fmt.Errorf("get task %d: %w", id, err)
+	}
+	return task, nil
+}
+
+// Update replaces the stored task with the same ID
+func (s *SQLStore) Update(task Task) error {
+	tags, blockedBy, err := encodeLists(task)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.Exec(
+		`UPDATE tasks SET title = $1, description = $2, done = $3, priority = $4, tags = $5, due_date = $6,
+		recurrence = $7, parent_id = $8, blocked_by = $9, created_at = $10, updated_at = $11, completed_at = $12
+		WHERE id = $13 AND deleted_at IS NULL`,
+		task.Title, task.Description, task.Done, int(task.Priority), tags, nullTime(task.DueDate),
+		nullRule(task.Recurrence), nullID(task.ParentID), blockedBy, task.CreatedAt.UTC(), task.UpdatedAt.UTC(), nullTime(task.CompletedAt),
+		task.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("update task %d: %w", task.ID, err)
+	}
+	return requireRow(result)
+}
+
+// Delete removes the task with the given ID
+func (s *SQLStore) Delete(id int) error {
+	result, err := s.db.Exec(`DELETE FROM tasks WHERE id = $1 AND deleted_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("delete task %d: %w", id, err)
+	}
+	return requireRow(result)
+}
+
+// Trash marks the task with the given ID as deleted at at
+func (s *SQLStore) Trash(id int, at time.Time) error {
+	result, err := s.db.Exec(`UPDATE tasks SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL`, at.UTC(), id)
+	if err != nil {
+		return fmt.Errorf("trash task %d: %w", id, err)
+	}
+	return requireRow(result)
+}
+
+// Trashed returns the tasks in the trash ordered by ID
+func (s *SQLStore) Trashed() ([]DeletedTask, error) {
+	rows, err := s.db.Query(`SELECT ` + taskColumns + `, deleted_at FROM tasks WHERE deleted_at IS NOT NULL ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("list trashed tasks: %w", err)
+	}
+	defer rows.Close()
+
+	trashed := []DeletedTask{}
+	for rows.Next() {
+		var deleted DeletedTask
+		deleted.Task, err = scanTask(rows, &deleted.DeletedAt)
+		if err != nil {
+			return nil, fmt.Errorf("list trashed tasks: %w", err)
+		}
+		trashed = append(trashed, deleted)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list trashed tasks: %w", err)
+	}
+	return trashed, nil
+}
+
+// Purge deletes a trashed task. Trashed sub-tasks lose their parent first so
+// the foreign key does not block the delete.
+func (s *SQLStore) Purge(id int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("purge task %d: %w", id, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE tasks SET parent_id = NULL WHERE parent_id = $1 AND deleted_at IS NOT NULL`, id); err != nil {
+		return fmt.Errorf("purge task %d: %w", id, err)
+	}
+	result, err := tx.Exec(`DELETE FROM tasks WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return fmt.Errorf("purge task %d: %w", id, err)
+	}
+	if err := requireRow(result); err != nil {
+		if errors.Is(err, ErrTaskNotFound) {
+			return ErrNotInTrash
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// Restore inserts a deleted task under its own ID, replacing its row in the
+// trash if it has one. Imported IDs may be beyond the ID sequence, which is
+// then moved past them.
+func (s *SQLStore) Restore(task Task) error {
+	tags, blockedBy, err := encodeLists(task)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.Exec(
+		`INSERT INTO tasks (id, title, description, done, priority, tags, due_date, recurrence, parent_id, blocked_by, created_at, updated_at, completed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (id) DO UPDATE SET title = excluded.title, description = excluded.description, done = excluded.done,
+			priority = excluded.priority, tags = excluded.tags, due_date = excluded.due_date, recurrence = excluded.recurrence,
+			parent_id = excluded.parent_id, blocked_by = excluded.blocked_by, created_at = excluded.created_at,
+			updated_at = excluded.updated_at, completed_at = excluded.completed_at, deleted_at = NULL
+		WHERE tasks.deleted_at IS NOT NULL`,
+		task.ID, task.Title, task.Description, task.Done, int(task.Priority), tags, nullTime(task.DueDate),
+		nullRule(task.Recurrence), nullID(task.ParentID), blockedBy, task.CreatedAt.UTC(), task.UpdatedAt.UTC(), nullTime(task.CompletedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("restore task %d: %w", task.ID, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrTaskExists
+	}
+	// SQLite's AUTOINCREMENT tracks explicit IDs itself; a Postgres sequence does not
+	if s.dialect == DialectPostgres {
+		_, err := s.db.Exec(`SELECT setval('tasks_id_seq', $1) FROM tasks_id_seq WHERE NOT is_called OR last_value < $1`, task.ID)
+		if err != nil {
+			return fmt.Errorf("advance task ID sequence: %w", err)
+		}
+	}
+	return nil
+}
+
+// List returns every task ordered by ID
+func (s *SQLStore) List() ([]Task, error) {
+	rows, err := s.db.Query(`SELECT ` + taskColumns + ` FROM tasks WHERE deleted_at IS NULL ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("list tasks: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := []Task{}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("list tasks: %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list tasks: %w", err)
+	}
+	return tasks, nil
+}
+
+// scanner is implemented by *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTask reads the taskColumns of one row, followed by any extra columns into extra
+func scanTask(row scanner, extra ...any) (Task, error) {
+	var (
+		task                 Task
+		priority             int
+		tags, blockedBy      string
+		recurrence           sql.NullString
+		dueDate, completedAt sql.NullTime
+		parentID             sql.NullInt64
+	)
+	dest := []any{&task.ID, &task.Title, &task.Description, &task.Done, &priority, &tags, &dueDate,
+		&recurrence, &parentID, &blockedBy, &task.CreatedAt, &task.UpdatedAt, &completedAt}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return Task{}, err
+	}
+
+	task.Priority = Priority(priority)
+	if err := json.Unmarshal([]byte(tags), &task.Tags); err != nil {
+		return Task{}, fmt.Errorf("decode tags of task %d: %w", task.ID, err)
+	}
+	if err := json.Unmarshal([]byte(blockedBy), &task.BlockedBy); err != nil {
+		return Task{}, fmt.Errorf("decode blockers of task %d: %w", task.ID, err)
+	}
+	if len(task.BlockedBy) == 0 {
+		task.BlockedBy = nil
+	}
+	if dueDate.Valid {
+		task.DueDate = &dueDate.Time
+	}
+	if completedAt.Valid {
+		task.CompletedAt = &completedAt.Time
+	}
+	if recurrence.Valid {
+		rule, err := ParseRRule(recurrence.String)
+		if err != nil {
+			return Task{}, fmt.Errorf("decode recurrence of task %d: %w", task.ID, err)
+		}
+		task.Recurrence = &rule
+	}
+	task.ParentID = int(parentID.Int64)
+	return task, nil
+}
+
+// encodeLists encodes the tags and blockers of task as JSON arrays
+func encodeLists(task Task) (string, string, error) {
+	tags, err := json.Marshal(task.Tags)
+	if err != nil {
+		return "", "", err
+	}
+	blockedBy := []byte("[]")
+	if len(task.BlockedBy) > 0 {
+		if blockedBy, err = json.Marshal(task.BlockedBy); err != nil {
+			return "", "", err
+		}
+	}
+	return string(tags), string(blockedBy), nil
+}
+
+// nullTime maps a nil time to SQL NULL
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+// nullRule maps a nil rule to SQL NULL
+func nullRule(rule *RRule) sql.NullString {
+	if rule == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: rule.String(), Valid: true}
+}
+
+// nullID maps the zero ID to SQL NULL
+func nullID(id int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id != 0}
+}
+
+// requireRow returns ErrTaskNotFound when a statement matched no rows
+func requireRow(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrTaskNotFound
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package taskmanager
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// TaskStore persists tasks for a TaskManager. Implementations must be safe for
+// concurrent use, assign increasing IDs on Create, never reuse them for new
+// tasks, and return ErrTaskNotFound for unknown IDs. Trashed tasks are kept
+// alongside the live ones but are invisible to Get, Update, Delete and List.
+type TaskStore interface {
+	// Create stores a new task, ignoring task.ID, and returns it with its assigned ID
+	Create(task Task) (Task, error)
+	// Get returns the task with the given ID
+	Get(id int) (Task, error)
+	// Update replaces the stored task with the same ID
+	Update(task Task) error
+	// Delete removes the task with the given ID for good
+	Delete(id int) error
+	// Trash removes the task with the given ID but keeps it, deleted at at, until it is restored or purged
+	Trash(id int, at time.Time) error
+	// Trashed returns the tasks in the trash ordered by ID
+	Trashed() ([]DeletedTask, error)
+	// Purge permanently removes a task from the trash, returning ErrNotInTrash if it is not there
+	Purge(id int) error
+	// Restore stores a previously deleted task under its own ID and takes it out of the trash,
+	// returning ErrTaskExists if the ID is taken
+	Restore(task Task) error
+	// List returns every task ordered by ID
+	List() ([]Task, error)
+}
+
+// MemoryStore keeps tasks in a map; everything is lost when the process exits
+type MemoryStore struct {
+	mu     sync.RWMutex
+	tasks  map[int]Task
+	trash  map[int]DeletedTask
+	nextID int
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		tasks:  make(map[int]Task),
+		trash:  make(map[int]DeletedTask),
+		nextID: 1,
+	}
+}
+
+// Create stores a new task under the next ID
+func (s *MemoryStore) Create(task Task) (Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task.ID = s.nextID
+	s.tasks[task.ID] = task.clone()
+	s.nextID++
+	return task, nil
+}
+
+// Get returns the task with the given ID
+func (s *MemoryStore) Get(id int) (Task, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	task, ok := s.tasks[id]
+	if !ok {
+		return Task{}, ErrTaskNotFound
+	}
+	return task.clone(), nil
+}
+
+// Update replaces the stored task with the same ID
+func (s *MemoryStore) Update(task Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tasks[task.ID]; !ok {
+		return ErrTaskNotFound
+	}
+	s.tasks[task.ID] = task.clone()
+	return nil
+}
+
+// Delete removes the task with the given ID
+func (s *MemoryStore) Delete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tasks[id]; !ok {
+		return ErrTaskNotFound
+	}
+	delete(s.tasks, id)
+	return nil
+}
+
+// Trash moves the task with the given ID to the trash
+func (s *MemoryStore) Trash(id int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, ok := s.tasks[id]
+	if !ok {
+		return ErrTaskNotFound
+	}
+	delete(s.tasks, id)
+	s.trash[id] = DeletedTask{Task: task, DeletedAt: at}
+	return nil
+}
+
+// Trashed returns the tasks in the trash ordered by ID
+func (s *MemoryStore) Trashed() ([]DeletedTask, error) {
+	s.mu.RLock()
+	trashed := make([]DeletedTask, 0, len(s.trash))
+	for _, deleted := range s.trash {
+		deleted.Task = deleted.Task.clone()
+		trashed = append(trashed, deleted)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(trashed, func(i, j int) bool {
+		return trashed[i].Task.ID < trashed[j].Task.ID
+	})
+	return trashed, nil
+}
+
+// Purge permanently removes a task from the trash
+func (s *MemoryStore) Purge(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.trash[id]; !ok {
+		return ErrNotInTrash
+	}
+	delete(s.trash, id)
+	return nil
+}
+
+// Restore stores a deleted task under its own ID
+func (s *MemoryStore) Restore(task Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tasks[task.ID]; ok {
+		return ErrTaskExists
+	}
+	delete(s.trash, task.ID)
+	s.tasks[task.ID] = task.clone()
+	if task.ID >= s.nextID {
+		s.nextID = task.ID + 1
+	}
+	return nil
+}
+
+// List returns every task ordered by ID
+func (s *MemoryStore) List() ([]Task, error) {
+	s.mu.RLock()
+	tasks := make([]Task, 0, len(s.tasks))
+	for _, task := range s.tasks {
+		tasks = append(tasks, task.clone())
+	}
+	s.mu.RUnlock()
+
+	// Map iteration order is random, so return tasks in creation order
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].ID < tasks[j].ID
+	})
+	return tasks, nil
+}
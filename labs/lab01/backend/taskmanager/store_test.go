@@ -0,0 +1,463 @@
+package taskmanager
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func newSQLiteStore(t *testing.T) *SQLStore {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "tasks.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	// SQLite allows a single writer; one connection avoids SQLITE_BUSY under concurrent tests
+	db.SetMaxOpenConns(1)
+
+	store := NewSQLStore(db, DialectSQLite)
+	if err := store.CreateSchema(); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+	return store
+}
+
+func newFileStore(t *testing.T) *FileStore {
+	t.Helper()
+
+	store, err := OpenFileStore(filepath.Join(t.TempDir(), "tasks.jsonl"))
+	if err != nil {
+		t.Fatalf("Failed to open file store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+var storeFactories = []struct {
+	name string
+	new  func(t *testing.T) TaskStore
+}{
+	{name: "memory", new: func(t *testing.T) TaskStore { return NewMemoryStore() }},
+	{name: "sharded", new: func(t *testing.T) TaskStore { return NewShardedMemoryStore(4) }},
+	{name: "sqlite", new: func(t *testing.T) TaskStore { return newSQLiteStore(t) }},
+	{name: "file", new: func(t *testing.T) TaskStore { return newFileStore(t) }},
+}
+
+func TestTaskManagerWithStores(t *testing.T) {
+	for _, factory := range storeFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			tm := NewTaskManagerWithStore(factory.new(t))
+
+			if _, err := tm.AddTask("", "x"); err != ErrEmptyTitle {
+				t.Errorf("Expected ErrEmptyTitle, got %v", err)
+			}
+
+			first, err := tm.AddTask("Task 1", "Description 1")
+			if err != nil {
+				t.Fatalf("Failed to add task: %v", err)
+			}
+			second, _ := tm.AddTask("Task 2", "Description 2")
+			if first.ID != 1 || second.ID != 2 {
+				t.Errorf("Expected IDs 1 and 2, got %d and %d", first.ID, second.ID)
+			}
+
+			got, err := tm.GetTask(first.ID)
+			if err != nil {
+				t.Fatalf("Failed to get task: %v", err)
+			}
+			if got.Title != "Task 1" || got.Description != "Description 1" || got.Done {
+				t.Errorf("Unexpected task: %+v", got)
+			}
+			if !got.CreatedAt.Equal(first.CreatedAt) {
+				t.Errorf("Expected CreatedAt %v, got %v", first.CreatedAt, got.CreatedAt)
+			}
+
+			if _, err := tm.UpdateTask(second.ID, TaskPatch{Title: ptr("Task 2b"), Done: ptr(true)}); err != nil {
+				t.Fatalf("Failed to update task: %v", err)
+			}
+			if _, err := tm.UpdateTask(999, TaskPatch{Title: ptr("x")}); err != ErrTaskNotFound {
+				t.Errorf("Expected ErrTaskNotFound, got %v", err)
+			}
+			if _, err := tm.UpdateTask(second.ID, TaskPatch{Title: ptr("")}); err != ErrEmptyTitle {
+				t.Errorf("Expected ErrEmptyTitle, got %v", err)
+			}
+
+			done := true
+			tasks := tm.ListTasks(&done)
+			if len(tasks) != 1 || tasks[0].Title != "Task 2b" {
+				t.Errorf("Expected only the updated task to be done, got %+v", tasks)
+			}
+
+			if _, err := tm.DeleteTask(second.ID); err != nil {
+				t.Fatalf("Failed to delete task: %v", err)
+			}
+			if _, err := tm.DeleteTask(second.ID); err != ErrTaskNotFound {
+				t.Errorf("Expected ErrTaskNotFound on second delete, got %v", err)
+			}
+			if _, err := tm.GetTask(second.ID); err != ErrTaskNotFound {
+				t.Errorf("Expected ErrTaskNotFound after delete, got %v", err)
+			}
+
+			// IDs of deleted tasks are never reused
+			third, _ := tm.AddTask("Task 3", "")
+			if third.ID != 3 {
+				t.Errorf("Expected ID 3, got %d", third.ID)
+			}
+
+			tasks = tm.ListTasks(nil)
+			if len(tasks) != 2 || tasks[0].ID != 1 || tasks[1].ID != 3 {
+				t.Errorf("Expected tasks 1 and 3 in order, got %+v", tasks)
+			}
+		})
+	}
+}
+
+func TestStoresRoundTripTaskFields(t *testing.T) {
+	due := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+
+	for _, factory := range storeFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			tm := NewTaskManagerWithStore(factory.new(t))
+			parent, _ := tm.AddTask("Parent", "")
+			child, err := tm.AddTask("Child", "", WithParent(parent.ID), WithDueDate(due), WithPriority(PriorityHigh), WithTags("a", "b"))
+			if err != nil {
+				t.Fatalf("Failed to add task: %v", err)
+			}
+			child, _ = tm.UpdateTask(child.ID, TaskPatch{Done: ptr(true)})
+
+			got, err := tm.GetTask(child.ID)
+			if err != nil {
+				t.Fatalf("Failed to get task: %v", err)
+			}
+			if got.ParentID != parent.ID || got.Priority != PriorityHigh || len(got.Tags) != 2 || got.Tags[1] != "b" {
+				t.Errorf("Fields lost in round trip: %+v", got)
+			}
+			if got.DueDate == nil || !got.DueDate.Equal(due) {
+				t.Errorf("Expected due date %v, got %v", due, got.DueDate)
+			}
+			if got.CompletedAt == nil || !got.CompletedAt.Equal(*child.CompletedAt) || !got.UpdatedAt.Equal(child.UpdatedAt) {
+				t.Errorf("Audit fields lost in round trip: %+v", got)
+			}
+
+			got, _ = tm.GetTask(parent.ID)
+			if got.ParentID != 0 || got.DueDate != nil || got.CompletedAt != nil || got.Recurrence != nil {
+				t.Errorf("Expected empty optional fields, got %+v", got)
+			}
+
+			rule, _ := ParseRRule("FREQ=MONTHLY;BYDAY=-1FR;COUNT=3")
+			recurring, err := tm.AddTask("Recurring", "", WithDueDate(due), WithRecurrence(rule), WithBlockers(child.ID, parent.ID))
+			if err != nil {
+				t.Fatalf("Failed to add recurring task: %v", err)
+			}
+			got, _ = tm.GetTask(recurring.ID)
+			if got.Recurrence == nil || got.Recurrence.String() != rule.String() {
+				t.Errorf("Expected recurrence %s, got %v", rule, got.Recurrence)
+			}
+			if !slices.Equal(got.BlockedBy, []int{parent.ID, child.ID}) {
+				t.Errorf("Expected blockers [%d %d], got %v", parent.ID, child.ID, got.BlockedBy)
+			}
+		})
+	}
+}
+
+func TestStoresCopyTasks(t *testing.T) {
+	for _, factory := range storeFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			tm := NewTaskManagerWithStore(factory.new(t))
+			blocker, _ := tm.AddTask("Blocker", "")
+			task, _ := tm.AddTask("a", "", WithTags("work"), WithBlockers(blocker.ID))
+
+			// Changing a returned task must not reach into the store
+			task.Tags[0] = "HACKED"
+			task.BlockedBy[0] = 99
+			got, _ := tm.GetTask(task.ID)
+			if got.Tags[0] != "work" || got.BlockedBy[0] != blocker.ID {
+				t.Errorf("Expected the stored task to be unchanged, got %+v", got)
+			}
+
+			updated, _ := tm.UpdateTask(task.ID, TaskPatch{Tags: &[]string{"home"}})
+			updated.Tags[0] = "HACKED"
+			got.Tags[0] = "HACKED"
+			if got, _ := tm.GetTask(task.ID); got.Tags[0] != "home" {
+				t.Errorf("Expected tags [home], got %v", got.Tags)
+			}
+		})
+	}
+}
+
+// faultyFile fails Write after writing part of the data, or fails Sync
+type faultyFile struct {
+	logFile
+	failWrite bool
+	failSync  bool
+}
+
+func (f *faultyFile) Write(data []byte) (int, error) {
+	if f.failWrite {
+		n, _ := f.logFile.Write(data[:len(data)/2])
+		return n, errors.New("no space left on device")
+	}
+	return f.logFile.Write(data)
+}
+
+func (f *faultyFile) Sync() error {
+	if f.failSync {
+		return errors.New("input/output error")
+	}
+	return f.logFile.Sync()
+}
+
+func TestFileStoreRollsBackFailedAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.jsonl")
+	store, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("Failed to open file store: %v", err)
+	}
+	faulty := &faultyFile{logFile: store.file}
+	store.file = faulty
+	tm := NewTaskManagerWithStore(store)
+	tm.AddTask("Kept", "")
+
+	faulty.failWrite = true
+	if _, err := tm.AddTask("Torn", ""); err == nil {
+		t.Error("Expected the torn append to fail")
+	}
+	faulty.failWrite, faulty.failSync = false, true
+	if _, err := tm.AddTask("Unsynced", ""); err == nil {
+		t.Error("Expected the unsynced append to fail")
+	}
+	faulty.failSync = false
+	tm.AddTask("After", "")
+	store.Close()
+
+	store, err = OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen file store: %v", err)
+	}
+	defer store.Close()
+
+	tasks, _ := store.List()
+	if len(tasks) != 2 || tasks[0].Title != "Kept" || tasks[1].Title != "After" {
+		t.Errorf("Expected only the successful appends, got %+v", tasks)
+	}
+}
+
+func TestStoresTrash(t *testing.T) {
+	deletedAt := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	for _, factory := range storeFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			store := factory.new(t)
+			kept, _ := store.Create(Task{Title: "Kept", CreatedAt: deletedAt, UpdatedAt: deletedAt})
+			task, _ := store.Create(Task{Title: "Trashed", Tags: []string{"a"}, CreatedAt: deletedAt, UpdatedAt: deletedAt})
+
+			if err := store.Trash(task.ID, deletedAt); err != nil {
+				t.Fatalf("Trash failed: %v", err)
+			}
+			if _, err := store.Get(task.ID); err != ErrTaskNotFound {
+				t.Errorf("Expected a trashed task to be hidden, got %v", err)
+			}
+			if err := store.Update(task); err != ErrTaskNotFound {
+				t.Errorf("Expected ErrTaskNotFound updating a trashed task, got %v", err)
+			}
+			if tasks, _ := store.List(); len(tasks) != 1 || tasks[0].ID != kept.ID {
+				t.Errorf("Expected only the kept task listed, got %+v", tasks)
+			}
+			trashed, err := store.Trashed()
+			if err != nil || len(trashed) != 1 || trashed[0].Task.Title != "Trashed" || !trashed[0].DeletedAt.Equal(deletedAt) {
+				t.Errorf("Expected the task in the trash, got %+v %v", trashed, err)
+			}
+
+			if err := store.Restore(task); err != nil {
+				t.Fatalf("Restore failed: %v", err)
+			}
+			if trashed, _ := store.Trashed(); len(trashed) != 0 {
+				t.Errorf("Expected restore to empty the trash, got %+v", trashed)
+			}
+			if err := store.Restore(task); err != ErrTaskExists {
+				t.Errorf("Expected ErrTaskExists, got %v", err)
+			}
+
+			store.Trash(task.ID, deletedAt)
+			if err := store.Purge(task.ID); err != nil {
+				t.Fatalf("Purge failed: %v", err)
+			}
+			if err := store.Purge(task.ID); err != ErrNotInTrash {
+				t.Errorf("Expected ErrNotInTrash, got %v", err)
+			}
+			if err := store.Purge(kept.ID); err != ErrNotInTrash {
+				t.Errorf("Expected ErrNotInTrash for a live task, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTrashSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	reopeners := []struct {
+		name string
+		open func(t *testing.T) (TaskStore, func())
+	}{
+		{name: "sqlite", open: func(t *testing.T) (TaskStore, func()) {
+			db, err := sql.Open("sqlite", filepath.Join(dir, "tasks.db"))
+			if err != nil {
+				t.Fatalf("Failed to open database: %v", err)
+			}
+			store := NewSQLStore(db, DialectSQLite)
+			if err := store.CreateSchema(); err != nil {
+				t.Fatalf("Failed to create schema: %v", err)
+			}
+			return store, func() { db.Close() }
+		}},
+		{name: "file", open: func(t *testing.T) (TaskStore, func()) {
+			store, err := OpenFileStore(filepath.Join(dir, "tasks.jsonl"))
+			if err != nil {
+				t.Fatalf("Failed to open file store: %v", err)
+			}
+			return store, func() { store.Close() }
+		}},
+	}
+
+	for _, reopener := range reopeners {
+		t.Run(reopener.name, func(t *testing.T) {
+			store, closeStore := reopener.open(t)
+			tm := NewTaskManagerWithStore(store)
+			task, _ := tm.AddTask("Deleted by accident", "", WithTags("work"))
+			tm.DeleteTask(task.ID)
+			closeStore()
+
+			store, closeStore = reopener.open(t)
+			defer closeStore()
+			tm = NewTaskManagerWithStore(store)
+
+			if trash := tm.Trash(); len(trash) != 1 || trash[0].Task.ID != task.ID {
+				t.Fatalf("Expected the task in the trash after a restart, got %+v", trash)
+			}
+			restored, err := tm.RestoreTask(task.ID)
+			if err != nil || restored.Title != task.Title || len(restored.Tags) != 1 {
+				t.Errorf("Expected the task back, got %+v %v", restored, err)
+			}
+		})
+	}
+}
+
+func TestFileStoreReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.jsonl")
+
+	store, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("Failed to open file store: %v", err)
+	}
+	tm := NewTaskManagerWithStore(store)
+	tm.AddTask("Task 1", "")
+	tm.AddTask("Task 2", "")
+	tm.UpdateTask(1, TaskPatch{Title: ptr("Task 1b"), Done: ptr(true)})
+	tm.DeleteTask(2)
+	store.Close()
+
+	// Simulate a crash in the middle of appending a record
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	f.WriteString(`{"op":"create","task":{"id":`)
+	f.Close()
+
+	store, err = OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen file store: %v", err)
+	}
+	defer store.Close()
+	tm = NewTaskManagerWithStore(store)
+
+	tasks := tm.ListTasks(nil)
+	if len(tasks) != 1 || tasks[0].Title != "Task 1b" || !tasks[0].Done {
+		t.Fatalf("Expected the replayed update, got %+v", tasks)
+	}
+
+	task, err := tm.AddTask("Task 3", "")
+	if err != nil {
+		t.Fatalf("Failed to add task after replay: %v", err)
+	}
+	if task.ID != 3 {
+		t.Errorf("Expected ID 3 after replay, got %d", task.ID)
+	}
+}
+
+func TestFileStoreKeepsFinalRecordWithoutNewline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.jsonl")
+	data := "{\"op\":\"create\",\"task\":{\"id\":1,\"title\":\"a\"}}\n{\"op\":\"create\",\"task\":{\"id\":2,\"title\":\"b\"}}"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("Failed to write log: %v", err)
+	}
+
+	store, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("Failed to open file store: %v", err)
+	}
+	if _, err := store.Get(2); err != nil {
+		t.Errorf("Expected the final record to be kept, got %v", err)
+	}
+	if _, err := store.Create(Task{Title: "c"}); err != nil {
+		t.Fatalf("Failed to append: %v", err)
+	}
+	store.Close()
+
+	store, err = OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen file store: %v", err)
+	}
+	defer store.Close()
+	if tasks, _ := store.List(); len(tasks) != 3 {
+		t.Errorf("Expected 3 tasks after reopening, got %+v", tasks)
+	}
+}
+
+func TestFileStoreRejectsCorruptLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.jsonl")
+	data := "{\"op\":\"create\",\"task\":{\"id\":1,\"title\":\"a\"}}\nnot json\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("Failed to write log: %v", err)
+	}
+
+	if _, err := OpenFileStore(path); err == nil {
+		t.Error("Expected an error for a corrupt line in the middle of the log")
+	}
+}
+
+func TestStoresRestore(t *testing.T) {
+	for _, factory := range storeFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			tm := NewTaskManagerWithStore(factory.new(t))
+			parent, _ := tm.AddTask("Parent", "")
+			child, _ := tm.AddTask("Child", "", WithParent(parent.ID), WithTags("a"))
+			tm.DeleteTask(child.ID)
+
+			if err := tm.Undo(); err != nil {
+				t.Fatalf("Undo failed: %v", err)
+			}
+			got, err := tm.GetTask(child.ID)
+			if err != nil || got.ParentID != parent.ID || len(got.Tags) != 1 {
+				t.Errorf("Expected the child back with its fields, got %+v %v", got, err)
+			}
+			if err := tm.store.Restore(got); err != ErrTaskExists {
+				t.Errorf("Expected ErrTaskExists, got %v", err)
+			}
+
+			// Restored IDs are never handed out again
+			tm.DeleteTask(child.ID)
+			next, _ := tm.AddTask("Next", "")
+			if next.ID != 3 {
+				t.Errorf("Expected ID 3, got %d", next.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package taskmanager
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Priority ranks how urgent a task is; the zero value means no priority
+type Priority int
+
+// Priority levels
+const (
+	PriorityNone Priority = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+)
+
+var priorityNames = map[Priority]string{
+	PriorityNone:   "none",
+	PriorityLow:    "low",
+	PriorityMedium: "medium",
+	PriorityHigh:   "high",
+}
+
+// ParsePriority converts a name such as "high" into a Priority
+func ParsePriority(name string) (Priority, error) {
+	for p, n := range priorityNames {
+		if strings.EqualFold(name, n) {
+			return p, nil
+		}
+	}
+	return PriorityNone, fmt.Errorf("%w: %q", ErrInvalidPriority, name)
+}
+
+// Valid reports whether p is one of the defined levels
+func (p Priority) Valid() bool {
+	_, ok := priorityNames[p]
+	return ok
+}
+
+// String returns the priority name
+func (p Priority) String() string {
+	if name, ok := priorityNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("Priority(%d)", int(p))
+}
+
+// MarshalText encodes the priority as its name
+func (p Priority) MarshalText() ([]byte, error) {
+	if !p.Valid() {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidPriority, int(p))
+	}
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText decodes a priority name
+func (p *Priority) UnmarshalText(text []byte) error {
+	parsed, err := ParsePriority(string(text))
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+// TaskOption sets an optional field when adding a task
+type TaskOption func(*Task)
+
+// WithDueDate sets the due date of a new task
+func WithDueDate(due time.Time) TaskOption {
+	return func(t *Task) { t.DueDate = &due }
+}
+
+// WithPriority sets the priority of a new task
+func WithPriority(p Priority) TaskOption {
+	return func(t *Task) { t.Priority = p }
+}
+
+// WithTags sets the tags of a new task
+func WithTags(tags ...string) TaskOption {
+	return func(t *Task) { t.Tags = tags }
+}
+
+// WithRecurrence makes a new task repeat according to rule, starting at its due date
+func WithRecurrence(rule RRule) TaskOption {
+	return func(t *Task) { t.Recurrence = &rule }
+}
+
+// WithParent makes a new task a sub-task of the task with the given ID
+func WithParent(id int) TaskOption {
+	return func(t *Task) { t.ParentID = id }
+}
+
+// WithBlockers makes a new task wait for the tasks with the given IDs
+func WithBlockers(ids ...int) TaskOption {
+	return func(t *Task) { t.BlockedBy = ids }
+}
+
+// TaskPatch is a partial update; nil fields keep their current value
+type TaskPatch struct {
+	Title       *string    `json:"title,omitempty"`
+	Description *string    `json:"description,omitempty"`
+	Done        *bool      `json:"done,omitempty"`
+	Priority    *Priority  `json:"priority,omitempty"`
+	Tags        *[]string  `json:"tags,omitempty"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	// ClearDueDate removes the due date; it wins over DueDate
+	ClearDueDate bool `json:"clear_due_date,omitempty"`
+	// ParentID moves the task under another task; 0 makes it top-level
+	ParentID *int `json:"parent_id,omitempty"`
+	// Recurrence replaces the recurrence rule; ClearRecurrence removes it and wins over Recurrence
+	Recurrence      *RRule `json:"recurrence,omitempty"`
+	ClearRecurrence bool   `json:"clear_recurrence,omitempty"`
+	// BlockedBy replaces the IDs of the tasks this task waits for
+	BlockedBy *[]int `json:"blocked_by,omitempty"`
+}
+
+// normalizeTags trims tags and drops empty and duplicate ones, keeping the first occurrence
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
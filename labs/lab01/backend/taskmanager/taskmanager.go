@@ -2,62 +2,407 @@ package taskmanager
 
 import (
 	"errors"
+	"slices"
+	"sync"
 	"time"
 )
 
 // Predefined errors
 var (
-	ErrTaskNotFound = errors.New("task not found")
-	ErrEmptyTitle   = errors.New("title cannot be empty")
+	ErrTaskNotFound    = errors.New("task not found")
+	ErrTaskExists      = errors.New("task already exists")
+	ErrEmptyTitle      = errors.New("title cannot be empty")
+	ErrInvalidPriority = errors.New("invalid priority")
+	ErrParentNotFound  = errors.New("parent task not found")
+	ErrInvalidParent   = errors.New("task cannot be its own ancestor")
+	ErrParentCompleted = errors.New("parent task is already completed")
+	ErrOpenSubtasks    = errors.New("task has open sub-tasks")
+	ErrHasSubtasks     = errors.New("task has sub-tasks")
 )
 
 // Task represents a single task
 type Task struct {
-	ID          int
-	Title       string
-	Description string
-	Done        bool
-	CreatedAt   time.Time
+	ID          int        `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Done        bool       `json:"done"`
+	Priority    Priority   `json:"priority"`
+	Tags        []string   `json:"tags"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	Recurrence  *RRule     `json:"recurrence,omitempty"`
+	ParentID    int        `json:"parent_id,omitempty"`
+	BlockedBy   []int      `json:"blocked_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
 }
 
-// TaskManager manages a collection of tasks
+// clone returns a copy of t that shares no memory with it
+func (t Task) clone() Task {
+	if t.Tags != nil {
+		t.Tags = append([]string(nil), t.Tags...)
+	}
+	t.BlockedBy = slices.Clone(t.BlockedBy)
+	if t.DueDate != nil {
+		due := *t.DueDate
+		t.DueDate = &due
+	}
+	if t.CompletedAt != nil {
+		completed := *t.CompletedAt
+		t.CompletedAt = &completed
+	}
+	if t.Recurrence != nil {
+		rule := *t.Recurrence
+		rule.ByDay = slices.Clone(rule.ByDay)
+		t.Recurrence = &rule
+	}
+	return t
+}
+
+// TaskManager manages a collection of tasks. It is safe for concurrent use.
 type TaskManager struct {
-	tasks  map[int]Task
-	nextID int
+	// mu serializes changes so checks spanning several tasks stay valid; the store guards reads
+	mu      sync.Mutex
+	store   TaskStore
+	history history
+	events  eventBus
+	now     func() time.Time
 }
 
-// NewTaskManager creates a new task manager
-func NewTaskManager() *TaskManager {
-	// TODO: Implement this function
-	return nil
+// NewTaskManager creates a new task manager backed by an in-memory store
+func NewTaskManager(opts ...ManagerOption) *TaskManager {
+	return NewTaskManagerWithStore(NewMemoryStore(), opts...)
 }
 
-// AddTask adds a new task to the manager, returns an error if the title is empty, and increments the nextID
-func (tm *TaskManager) AddTask(title, description string) (Task, error) {
-	// TODO: Implement this function
-	return Task{}, nil
+// NewTaskManagerWithStore creates a new task manager backed by store
+func NewTaskManagerWithStore(store TaskStore, opts ...ManagerOption) *TaskManager {
+	tm := &TaskManager{store: store, history: newHistory(), now: time.Now}
+	for _, opt := range opts {
+		opt(tm)
+	}
+	return tm
 }
 
-// UpdateTask updates an existing task, returns an error if the title is empty or the task is not found
-func (tm *TaskManager) UpdateTask(id int, title, description string, done bool) error {
-	// TODO: Implement this function
-	return nil
+// AddTask adds a new task to the manager, returns an error if the title is empty, an option is invalid
+// or the store fails
+func (tm *TaskManager) AddTask(title, description string, opts ...TaskOption) (Task, error) {
+	if title == "" {
+		return Task{}, ErrEmptyTitle
+	}
+
+	now := tm.now()
+	task := Task{
+		Title:       title,
+		Description: description,
+		Done:        false,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	for _, opt := range opts {
+		opt(&task)
+	}
+	task.Tags = normalizeTags(task.Tags)
+	task.BlockedBy = normalizeBlockers(task.BlockedBy)
+	if err := validateTask(task); err != nil {
+		return Task{}, err
+	}
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	if task.ParentID != 0 {
+		if err := tm.checkParent(task); err != nil {
+			return Task{}, err
+		}
+	}
+	if err := tm.checkBlockers(task); err != nil {
+		return Task{}, err
+	}
+	created, err := tm.store.Create(task)
+	if err != nil {
+		return Task{}, err
+	}
+	tm.record(command{{after: &created}}, now)
+	return created, nil
+}
+
+// UpdateTask applies patch to an existing task and returns the result. Fields left nil in the patch keep
+// their values. Returns an error if the task is not found, the patch is invalid, or the task would be
+// completed while it has open sub-tasks.
+func (tm *TaskManager) UpdateTask(id int, patch TaskPatch) (Task, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	return tm.updateTask(id, patch)
+}
+
+// updateTask implements UpdateTask; the caller holds mu
+func (tm *TaskManager) updateTask(id int, patch TaskPatch) (Task, error) {
+	task, err := tm.store.Get(id)
+	if err != nil {
+		return Task{}, err
+	}
+	before := task.clone()
+	wasDone := task.Done
+
+	if patch.Title != nil {
+		if *patch.Title == "" {
+			return Task{}, ErrEmptyTitle
+		}
+		task.Title = *patch.Title
+	}
+	if patch.Description != nil {
+		task.Description = *patch.Description
+	}
+	if patch.Priority != nil {
+		if !patch.Priority.Valid() {
+			return Task{}, ErrInvalidPriority
+		}
+		task.Priority = *patch.Priority
+	}
+	if patch.Tags != nil {
+		task.Tags = normalizeTags(*patch.Tags)
+	}
+	if patch.ClearDueDate {
+		task.DueDate = nil
+	} else if patch.DueDate != nil {
+		due := *patch.DueDate
+		task.DueDate = &due
+	}
+	if patch.ClearRecurrence {
+		task.Recurrence = nil
+	} else if patch.Recurrence != nil {
+		rule := *patch.Recurrence
+		task.Recurrence = &rule
+	}
+	if err := validateRecurrence(task); err != nil {
+		return Task{}, err
+	}
+	if patch.Done != nil {
+		task.Done = *patch.Done
+	}
+	if patch.ParentID != nil {
+		task.ParentID = *patch.ParentID
+	}
+
+	if task.ParentID != 0 && (patch.ParentID != nil || (wasDone && !task.Done)) {
+		if err := tm.checkParent(task); err != nil {
+			return Task{}, err
+		}
+	}
+	if patch.BlockedBy != nil {
+		task.BlockedBy = normalizeBlockers(*patch.BlockedBy)
+		if err := tm.checkBlockers(task); err != nil {
+			return Task{}, err
+		}
+	}
+
+	now := tm.now()
+	if task.Done && !wasDone {
+		open, err := tm.hasOpenSubtasks(id)
+		if err != nil {
+			return Task{}, err
+		}
+		if open {
+			return Task{}, ErrOpenSubtasks
+		}
+		task.CompletedAt = &now
+	} else if !task.Done {
+		task.CompletedAt = nil
+	}
+	task.UpdatedAt = now
+
+	var next *Task
+	if task.Done && !wasDone && task.Recurrence != nil {
+		next = tm.nextOccurrence(task)
+		if next != nil {
+			// The series moves on to the new task, so reopening and completing this one again does
+			// not schedule a second copy
+			task.Recurrence = nil
+		}
+	}
+
+	// The next occurrence is created first, so a failure leaves the series with the open task
+	var scheduled command
+	if next != nil {
+		occurrence, err := tm.store.Create(*next)
+		if err != nil {
+			return Task{}, err
+		}
+		scheduled = command{{after: &occurrence}}
+	}
+	if err := tm.store.Update(task); err != nil {
+		return Task{}, tm.revert(scheduled, err, now)
+	}
+	tm.record(append(command{{before: &before, after: &task}}, scheduled...), now)
+	return task.clone(), nil
+}
+
+// nextOccurrence builds the task that follows a completed recurring task, or returns nil if the series has
+// ended. The new task is due at the next occurrence and carries the remaining COUNT.
+func (tm *TaskManager) nextOccurrence(done Task) *Task {
+	rule := *done.Recurrence
+	due, ok := rule.Next(*done.DueDate, *done.DueDate)
+	if !ok {
+		return nil
+	}
+	if rule.Count > 0 {
+		rule.Count--
+	}
+
+	now := tm.now()
+	return &Task{
+		Title:       done.Title,
+		Description: done.Description,
+		Priority:    done.Priority,
+		Tags:        done.Tags,
+		DueDate:     &due,
+		Recurrence:  &rule,
+		ParentID:    done.ParentID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// validateTask checks the fields every new task must satisfy
+func validateTask(task Task) error {
+	if task.Title == "" {
+		return ErrEmptyTitle
+	}
+	if !task.Priority.Valid() {
+		return ErrInvalidPriority
+	}
+	return validateRecurrence(task)
 }
 
-// DeleteTask removes a task from the manager, returns an error if the task is not found
-func (tm *TaskManager) DeleteTask(id int) error {
-	// TODO: Implement this function
+// validateRecurrence checks that a recurring task has a valid rule and a due date to anchor it
+func validateRecurrence(task Task) error {
+	if task.Recurrence == nil {
+		return nil
+	}
+	if err := task.Recurrence.Validate(); err != nil {
+		return err
+	}
+	if task.DueDate == nil {
+		return ErrRecurrenceNeedsDueDate
+	}
 	return nil
 }
 
+// DeleteTask moves a task to the trash and removes it from the blockers of other tasks. It returns the
+// open tasks it was the last open blocker of, or an error if the task is not found or has sub-tasks. If
+// the store fails, nothing is changed.
+func (tm *TaskManager) DeleteTask(id int) ([]Task, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	task, err := tm.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	subtasks, err := tm.subtasks(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(subtasks) > 0 {
+		return nil, ErrHasSubtasks
+	}
+
+	now := tm.now()
+	unlinked, unblocked, err := tm.unlinkBlocker(id, now)
+	if err != nil {
+		return nil, err
+	}
+	trashed := change{before: &task, trash: true}
+	if err := tm.applyAll(append(command{trashed}, unlinked...), now); err != nil {
+		return nil, err
+	}
+
+	tm.purgeExpired(now)
+	// The dependents come first so undo restores the task before pointing them back at it
+	tm.record(append(unlinked, trashed), now)
+	return unblocked, nil
+}
+
 // GetTask retrieves a task by ID, returns an error if the task is not found
 func (tm *TaskManager) GetTask(id int) (Task, error) {
-	// TODO: Implement this function
-	return Task{}, nil
+	return tm.store.Get(id)
+}
+
+// Subtasks returns the direct sub-tasks of a task ordered by ID, returns an error if the task is not found
+func (tm *TaskManager) Subtasks(id int) ([]Task, error) {
+	if _, err := tm.store.Get(id); err != nil {
+		return nil, err
+	}
+	return tm.subtasks(id)
 }
 
-// ListTasks returns all tasks, optionally filtered by done status, returns an empty slice if no tasks are found
+// ListTasks returns all tasks ordered by ID, optionally filtered by done status, returns an empty slice if no
+// tasks are found or the store cannot be read. Use QueryTasks for other filters, sorting and pagination.
 func (tm *TaskManager) ListTasks(filterDone *bool) []Task {
-	// TODO: Implement this function
+	page, err := tm.QueryTasks(ListOptions{Done: filterDone})
+	if err != nil {
+		return []Task{}
+	}
+	return page.Tasks
+}
+
+// subtasks returns the direct children of id
+func (tm *TaskManager) subtasks(id int) ([]Task, error) {
+	all, err := tm.store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	children := []Task{}
+	for _, task := range all {
+		if task.ParentID == id {
+			children = append(children, task)
+		}
+	}
+	return children, nil
+}
+
+// hasOpenSubtasks reports whether any direct child of id is not done
+func (tm *TaskManager) hasOpenSubtasks(id int) (bool, error) {
+	children, err := tm.subtasks(id)
+	if err != nil {
+		return false, err
+	}
+	for _, child := range children {
+		if !child.Done {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// checkParent verifies that task.ParentID exists, is not task itself or one of its descendants, and can
+// take an open sub-task
+func (tm *TaskManager) checkParent(task Task) error {
+	parent, err := tm.store.Get(task.ParentID)
+	if errors.Is(err, ErrTaskNotFound) {
+		return ErrParentNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	// Walk up from the new parent; reaching the task itself means a cycle
+	for ancestor := parent; ; {
+		if ancestor.ID == task.ID {
+			return ErrInvalidParent
+		}
+		if ancestor.ParentID == 0 {
+			break
+		}
+		if ancestor, err = tm.store.Get(ancestor.ParentID); err != nil {
+			return err
+		}
+	}
+
+	if !task.Done && parent.Done {
+		return ErrParentCompleted
+	}
 	return nil
 }
@@ -1,7 +1,11 @@
 package taskmanager
 
 import (
+	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewTaskManager(t *testing.T) {
@@ -9,11 +13,15 @@ func TestNewTaskManager(t *testing.T) {
 	if tm == nil {
 		t.Error("NewTaskManager() returned nil")
 	}
-	if tm.tasks == nil {
+	store, ok := tm.store.(*MemoryStore)
+	if !ok {
+		t.Fatalf("Expected a *MemoryStore, got %T", tm.store)
+	}
+	if store.tasks == nil {
 		t.Error("tasks map is nil")
 	}
-	if tm.nextID != 1 {
-		t.Errorf("Expected nextID to be 1, got %d", tm.nextID)
+	if store.nextID != 1 {
+		t.Errorf("Expected nextID to be 1, got %d", store.nextID)
 	}
 }
 
@@ -167,7 +175,7 @@ func TestUpdateTask(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tm.UpdateTask(tt.id, tt.title, tt.description, tt.done)
+			_, err := tm.UpdateTask(tt.id, TaskPatch{Title: &tt.title, Description: &tt.description, Done: &tt.done})
 
 			if tt.expectError {
 				if err == nil {
@@ -227,7 +235,7 @@ func TestDeleteTask(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tm.DeleteTask(tt.id)
+			_, err := tm.DeleteTask(tt.id)
 
 			if tt.expectError {
 				if err == nil {
@@ -262,7 +270,7 @@ func TestListTasks(t *testing.T) {
 	_, _ = tm.AddTask("Task 3", "Description 3")
 
 	// Mark one task as done
-	tm.UpdateTask(task2.ID, task2.Title, task2.Description, true)
+	tm.UpdateTask(task2.ID, TaskPatch{Done: ptr(true)})
 
 	tests := []struct {
 		name     string
@@ -304,3 +312,150 @@ func TestListTasks(t *testing.T) {
 		})
 	}
 }
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// newTestManager returns a manager whose clock advances one minute per call
+func newTestManager() *TaskManager {
+	tm := NewTaskManager()
+	clock := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	tm.now = func() time.Time {
+		clock = clock.Add(time.Minute)
+		return clock
+	}
+	return tm
+}
+
+func TestAddTaskOptions(t *testing.T) {
+	tm := newTestManager()
+	due := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+
+	task, err := tm.AddTask("Ship release", "", WithDueDate(due), WithPriority(PriorityHigh), WithTags(" release", "go", "release", ""))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if task.DueDate == nil || !task.DueDate.Equal(due) {
+		t.Errorf("Expected due date %v, got %v", due, task.DueDate)
+	}
+	if task.Priority != PriorityHigh {
+		t.Errorf("Expected priority high, got %v", task.Priority)
+	}
+	if len(task.Tags) != 2 || task.Tags[0] != "release" || task.Tags[1] != "go" {
+		t.Errorf("Expected tags [release go], got %v", task.Tags)
+	}
+	if !task.UpdatedAt.Equal(task.CreatedAt) || task.CompletedAt != nil {
+		t.Errorf("Unexpected audit fields: updated %v, completed %v", task.UpdatedAt, task.CompletedAt)
+	}
+
+	if _, err := tm.AddTask("Bad", "", WithPriority(Priority(42))); err != ErrInvalidPriority {
+		t.Errorf("Expected ErrInvalidPriority, got %v", err)
+	}
+	if _, err := tm.AddTask("Orphan", "", WithParent(999)); err != ErrParentNotFound {
+		t.Errorf("Expected ErrParentNotFound, got %v", err)
+	}
+}
+
+func TestUpdateTaskPatch(t *testing.T) {
+	tm := newTestManager()
+	due := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	task, _ := tm.AddTask("Write docs", "API reference", WithDueDate(due), WithPriority(PriorityLow), WithTags("docs"))
+
+	// Only the priority changes
+	updated, err := tm.UpdateTask(task.ID, TaskPatch{Priority: ptr(PriorityMedium)})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if updated.Title != "Write docs" || updated.Description != "API reference" || len(updated.Tags) != 1 || updated.DueDate == nil {
+		t.Errorf("Untouched fields changed: %+v", updated)
+	}
+	if updated.Priority != PriorityMedium {
+		t.Errorf("Expected priority medium, got %v", updated.Priority)
+	}
+	if !updated.UpdatedAt.After(task.UpdatedAt) {
+		t.Errorf("Expected UpdatedAt to advance, got %v", updated.UpdatedAt)
+	}
+
+	updated, _ = tm.UpdateTask(task.ID, TaskPatch{Done: ptr(true), ClearDueDate: true, Tags: &[]string{}})
+	if updated.CompletedAt == nil || !updated.CompletedAt.Equal(updated.UpdatedAt) {
+		t.Errorf("Expected CompletedAt to be set on completion, got %v", updated.CompletedAt)
+	}
+	if updated.DueDate != nil || len(updated.Tags) != 0 {
+		t.Errorf("Expected due date and tags to be cleared, got %v and %v", updated.DueDate, updated.Tags)
+	}
+
+	updated, _ = tm.UpdateTask(task.ID, TaskPatch{Done: ptr(false)})
+	if updated.CompletedAt != nil {
+		t.Errorf("Expected CompletedAt to be cleared on reopen, got %v", updated.CompletedAt)
+	}
+
+	if _, err := tm.UpdateTask(task.ID, TaskPatch{Priority: ptr(Priority(-1))}); err != ErrInvalidPriority {
+		t.Errorf("Expected ErrInvalidPriority, got %v", err)
+	}
+}
+
+func TestSubtasks(t *testing.T) {
+	tm := newTestManager()
+	parent, _ := tm.AddTask("Release", "")
+	child, err := tm.AddTask("Changelog", "", WithParent(parent.ID))
+	if err != nil {
+		t.Fatalf("Failed to add sub-task: %v", err)
+	}
+	grandchild, _ := tm.AddTask("Collect PRs", "", WithParent(child.ID))
+
+	subtasks, err := tm.Subtasks(parent.ID)
+	if err != nil || len(subtasks) != 1 || subtasks[0].ID != child.ID {
+		t.Errorf("Expected one sub-task, got %v (%v)", subtasks, err)
+	}
+
+	if _, err := tm.UpdateTask(parent.ID, TaskPatch{Done: ptr(true)}); err != ErrOpenSubtasks {
+		t.Errorf("Expected ErrOpenSubtasks, got %v", err)
+	}
+	if _, err := tm.UpdateTask(parent.ID, TaskPatch{ParentID: ptr(grandchild.ID)}); err != ErrInvalidParent {
+		t.Errorf("Expected ErrInvalidParent for a cycle, got %v", err)
+	}
+	if _, err := tm.UpdateTask(parent.ID, TaskPatch{ParentID: ptr(parent.ID)}); err != ErrInvalidParent {
+		t.Errorf("Expected ErrInvalidParent for a self reference, got %v", err)
+	}
+	if _, err := tm.DeleteTask(parent.ID); err != ErrHasSubtasks {
+		t.Errorf("Expected ErrHasSubtasks, got %v", err)
+	}
+
+	tm.UpdateTask(grandchild.ID, TaskPatch{Done: ptr(true)})
+	tm.UpdateTask(child.ID, TaskPatch{Done: ptr(true)})
+	if _, err := tm.UpdateTask(parent.ID, TaskPatch{Done: ptr(true)}); err != nil {
+		t.Errorf("Expected parent to complete once children are done, got %v", err)
+	}
+
+	if _, err := tm.UpdateTask(child.ID, TaskPatch{Done: ptr(false)}); err != ErrParentCompleted {
+		t.Errorf("Expected ErrParentCompleted when reopening under a done parent, got %v", err)
+	}
+	if _, err := tm.AddTask("Late", "", WithParent(parent.ID)); err != ErrParentCompleted {
+		t.Errorf("Expected ErrParentCompleted when adding under a done parent, got %v", err)
+	}
+
+	// Detaching makes the task top-level
+	detached, err := tm.UpdateTask(grandchild.ID, TaskPatch{ParentID: ptr(0)})
+	if err != nil || detached.ParentID != 0 {
+		t.Errorf("Expected task to be detached, got %+v (%v)", detached, err)
+	}
+}
+
+func TestPriorityJSON(t *testing.T) {
+	data, err := json.Marshal(Task{ID: 1, Title: "x", Priority: PriorityHigh})
+	if err != nil {
+		t.Fatalf("Failed to marshal task: %v", err)
+	}
+	if !strings.Contains(string(data), `"priority":"high"`) {
+		t.Errorf("Expected priority as a name, got %s", data)
+	}
+
+	var task Task
+	if err := json.Unmarshal([]byte(`{"title":"x","priority":"Medium"}`), &task); err != nil || task.Priority != PriorityMedium {
+		t.Errorf("Expected priority medium, got %v (%v)", task.Priority, err)
+	}
+	if err := json.Unmarshal([]byte(`{"priority":"urgent"}`), &task); !errors.Is(err, ErrInvalidPriority) {
+		t.Errorf("Expected ErrInvalidPriority, got %v", err)
+	}
+}
@@ -2,6 +2,10 @@ package user
 
 import (
 	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
 )
 
 // Predefined errors
@@ -11,6 +15,9 @@ var (
 	ErrInvalidEmail = errors.New("invalid email format")
 )
 
+// emailPattern accepts a local part, an @ and a domain with a top-level domain
+var emailPattern = regexp.MustCompile(`^[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}$`)
+
 // User represents a user in the system
 type User struct {
 	Name  string
@@ -18,50 +25,93 @@ type User struct {
 	Email string
 }
 
-// Validate checks if the user data is valid, returns an error for each invalid field
-func (u *User) Validate() error {
-	if !IsValidName(u.Name) {
-		return ErrInvalidName
+// FieldError describes one invalid field. Err is the matching predefined error.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Err     error  `json:"-"`
+}
+
+func (e FieldError) Error() string {
+	return e.Message
+}
+
+func (e FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors lists every invalid field of a user, in field order. It
+// matches each field's predefined error with errors.Is.
+type ValidationErrors []FieldError
+
+func (v ValidationErrors) Error() string {
+	messages := make([]string, len(v))
+	for i, fieldErr := range v {
+		messages[i] = fieldErr.Message
 	}
+	return strings.Join(messages, "; ")
+}
 
-	if !IsValidAge(u.Age) {
-		return ErrInvalidAge
+func (v ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(v))
+	for i, fieldErr := range v {
+		errs[i] = fieldErr
 	}
+	return errs
+}
 
-	if !IsValidEmail(u.Email) {
-		return ErrInvalidEmail
+// Validate checks if the user data is valid. It returns nil or a
+// ValidationErrors with an entry for each invalid field.
+func (u *User) Validate() error {
+	checks := []struct {
+		field string
+		code  string
+		valid bool
+		err   error
+	}{
+		{"name", "invalid_name", IsValidName(u.Name), ErrInvalidName},
+		{"age", "invalid_age", IsValidAge(u.Age), ErrInvalidAge},
+		{"email", "invalid_email", IsValidEmail(u.Email), ErrInvalidEmail},
 	}
 
-	return nil
+	var errs ValidationErrors
+	for _, check := range checks {
+		if !check.valid {
+			errs = append(errs, FieldError{Field: check.field, Code: check.code, Message: check.err.Error(), Err: check.err})
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
 }
 
 // String returns a string representation of the user, formatted as "Name: <name>, Age: <age>, Email: <email>"
 func (u *User) String() string {
-	// TODO: Implement this function
-	return ""
+	return fmt.Sprintf("Name: %s, Age: %d, Email: %s", u.Name, u.Age, u.Email)
 }
 
 // NewUser creates a new user with validation, returns an error if the user is not valid
 func NewUser(name string, age int, email string) (*User, error) {
-	// TODO: Implement this function
-	return nil, nil
+	u := &User{Name: name, Age: age, Email: email}
+	if err := u.Validate(); err != nil {
+		return nil, err
+	}
+	return u, nil
 }
 
 // IsValidEmail checks if the email format is valid
-// You can use regexp.MustCompile to compile the email regex
 func IsValidEmail(email string) bool {
-	// TODO: Implement this function
-	return false
+	return emailPattern.MatchString(email)
 }
 
 // IsValidName checks if the name is valid, returns false if the name is empty or longer than 30 characters
 func IsValidName(name string) bool {
-	// TODO: Implement this function
-	return false
+	return strings.TrimSpace(name) != "" && utf8.RuneCountInString(name) <= 30
 }
 
 // IsValidAge checks if the age is valid, returns false if the age is not between 0 and 150
 func IsValidAge(age int) bool {
-	// TODO: Implement this function
-	return false
-}
\ No newline at end of file
+	return age >= 0 && age <= 150
+}
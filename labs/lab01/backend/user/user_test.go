@@ -1,6 +1,8 @@
 package user
 
 import (
+	"encoding/json"
+	"errors"
 	"testing"
 )
 
@@ -78,7 +80,7 @@ func TestNewUser(t *testing.T) {
 				if err == nil {
 					t.Error("Expected error, got none")
 				}
-				if err != tt.errorType {
+				if !errors.Is(err, tt.errorType) {
 					t.Errorf("Expected error %v, got %v", tt.errorType, err)
 				}
 				return
@@ -168,7 +170,7 @@ func TestUserValidate(t *testing.T) {
 				if err == nil {
 					t.Error("Expected error, got none")
 				}
-				if err != tt.errorType {
+				if !errors.Is(err, tt.errorType) {
 					t.Errorf("Expected error %v, got %v", tt.errorType, err)
 				}
 				return
@@ -224,3 +226,31 @@ func TestUserString(t *testing.T) {
 		})
 	}
 }
+
+func TestValidationErrors(t *testing.T) {
+	u := User{Name: "", Age: 200, Email: "john@example.com"}
+	err := u.Validate()
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("Expected two field errors, got %v", err)
+	}
+	if !errors.Is(err, ErrInvalidName) || !errors.Is(err, ErrInvalidAge) || errors.Is(err, ErrInvalidEmail) {
+		t.Errorf("Expected errors.Is to match the name and age errors only, got %v", err)
+	}
+	expected := ErrInvalidName.Error() + "; " + ErrInvalidAge.Error()
+	if err.Error() != expected {
+		t.Errorf("Expected %q, got %q", expected, err.Error())
+	}
+
+	data, _ := json.Marshal(errs)
+	expectedJSON := `[{"field":"name","code":"invalid_name","message":"invalid name: must be between 1 and 30 characters"},` +
+		`{"field":"age","code":"invalid_age","message":"invalid age: must be between 0 and 150"}]`
+	if string(data) != expectedJSON {
+		t.Errorf("Expected %s, got %s", expectedJSON, data)
+	}
+
+	if _, err := NewUser(" ", -1, "nope"); !errors.As(err, &errs) || len(errs) != 3 {
+		t.Errorf("Expected NewUser to report all three fields, got %v", err)
+	}
+}